@@ -34,6 +34,7 @@ func alphaCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service)
 		vizCommand(p, dockerCli, backend),
 		publishCommand(p, dockerCli, backend),
 		generateCommand(p, backend),
+		pullProjectCommand(dockerCli),
 	)
 	return cmd
 }
@@ -35,15 +35,19 @@ import (
 
 type buildOptions struct {
 	*ProjectOptions
-	quiet   bool
-	pull    bool
-	push    bool
-	args    []string
-	noCache bool
-	memory  cliopts.MemBytes
-	ssh     string
-	builder string
-	deps    bool
+	quiet     bool
+	pull      bool
+	push      bool
+	args      []string
+	noCache   bool
+	memory    cliopts.MemBytes
+	ssh       string
+	builder   string
+	deps      bool
+	summary   bool
+	report    string
+	platforms []string
+	targets   []string
 }
 
 func (opts buildOptions) toAPIBuildOptions(services []string) (api.BuildOptions, error) {
@@ -64,20 +68,26 @@ func (opts buildOptions) toAPIBuildOptions(services []string) (api.BuildOptions,
 	}
 
 	uiMode := ui.Mode
-	if uiMode == ui.ModeJSON {
-		uiMode = "rawjson"
+	switch uiMode {
+	case ui.ModeJUnit, ui.ModeMarkdown:
+		// BuildKit has no report mode, the build steps are reported as plain progress
+		uiMode = ui.ModePlain
 	}
 	return api.BuildOptions{
-		Pull:     opts.pull,
-		Push:     opts.push,
-		Progress: uiMode,
-		Args:     types.NewMappingWithEquals(opts.args),
-		NoCache:  opts.noCache,
-		Quiet:    opts.quiet,
-		Services: services,
-		Deps:     opts.deps,
-		SSHs:     SSHKeys,
-		Builder:  builderName,
+		Pull:      opts.pull,
+		Push:      opts.push,
+		Progress:  uiMode,
+		Args:      types.NewMappingWithEquals(opts.args),
+		NoCache:   opts.noCache,
+		Quiet:     opts.quiet,
+		Services:  services,
+		Deps:      opts.deps,
+		SSHs:      SSHKeys,
+		Builder:   builderName,
+		Summary:   opts.summary,
+		Report:    opts.report,
+		Platforms: opts.platforms,
+		Targets:   opts.targets,
 	}, nil
 }
 
@@ -118,6 +128,10 @@ func buildCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service)
 	flags.StringVar(&opts.ssh, "ssh", "", "Set SSH authentications used when building service images. (use 'default' for using your default SSH Agent)")
 	flags.StringVar(&opts.builder, "builder", "", "Set builder to use")
 	flags.BoolVar(&opts.deps, "with-dependencies", false, "Also build dependencies (transitively)")
+	flags.BoolVar(&opts.summary, "summary", false, "Print build duration, cache usage and image size per service")
+	flags.StringVar(&opts.report, "report", "", "Write build duration, cache usage and image size per service to a JSON file")
+	flags.StringSliceVar(&opts.platforms, "platform", nil, "Build selected services for each of these platforms, tagging images per variant")
+	flags.StringSliceVar(&opts.targets, "target", nil, "Build selected services for each of these stages, tagging images per variant")
 
 	flags.Bool("parallel", true, "Build images in parallel. DEPRECATED")
 	flags.MarkHidden("parallel") //nolint:errcheck
@@ -0,0 +1,96 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"maps"
+	"strings"
+
+	interp "github.com/compose-spec/compose-go/v2/interpolation"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/tree"
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+// serviceContextMark replaces the `service:` prefix of additional build contexts while a project is loaded. compose-go
+// resolves additional contexts as relative paths unless they look like a URL, which would turn `service:name` into
+// `<working_dir>/service:name`
+const serviceContextMark = "service://"
+
+var additionalContextsPath = tree.NewPath("services", tree.PathMatchAll, "build", "additional_contexts", tree.PathMatchAll)
+
+// withServiceBuildContexts is a load option marking the additional build contexts referring to a service as they are
+// interpolated, before paths are resolved. restoreServiceBuildContexts turns them back into `service:name`
+func withServiceBuildContexts(opts *loader.Options) {
+	if opts.Interpolate == nil {
+		return
+	}
+	interpolate := *opts.Interpolate
+	interpolate.TypeCastMapping = maps.Clone(interpolate.TypeCastMapping)
+	if interpolate.TypeCastMapping == nil {
+		interpolate.TypeCastMapping = map[tree.Path]interp.Cast{}
+	}
+	interpolate.TypeCastMapping[additionalContextsPath] = markServiceContext
+	opts.Interpolate = &interpolate
+}
+
+// markServiceContext marks an additional build context referring to a service, set as a value of the mapping or as a
+// `name=service:ref` list entry
+func markServiceContext(value string) (any, error) {
+	if ref, ok := strings.CutPrefix(value, types.ServicePrefix); ok && !strings.HasPrefix(value, serviceContextMark) {
+		return serviceContextMark + ref, nil
+	}
+	if name, contextPath, ok := strings.Cut(value, "="); ok {
+		if ref, ok := strings.CutPrefix(contextPath, types.ServicePrefix); ok && !strings.HasPrefix(contextPath, serviceContextMark) {
+			return name + "=" + serviceContextMark + ref, nil
+		}
+	}
+	return value, nil
+}
+
+// restoreServiceBuildContexts sets back the `service:` prefix of the additional build contexts marked while loading
+func restoreServiceBuildContexts(project *types.Project) {
+	for name, service := range project.Services {
+		if service.Build == nil {
+			continue
+		}
+		for key, contextPath := range service.Build.AdditionalContexts {
+			if ref, ok := strings.CutPrefix(contextPath, serviceContextMark); ok {
+				service.Build.AdditionalContexts[key] = types.ServicePrefix + ref
+			}
+		}
+		project.Services[name] = service
+	}
+}
+
+// restoreModelServiceBuildContexts sets back the `service:` prefix of the additional build contexts marked while
+// loading the model of a project
+func restoreModelServiceBuildContexts(model map[string]any) {
+	services, _ := model["services"].(map[string]any)
+	for _, value := range services {
+		service, _ := value.(map[string]any)
+		build, _ := service["build"].(map[string]any)
+		contexts, _ := build["additional_contexts"].(map[string]any)
+		for key, contextPath := range contexts {
+			if s, ok := contextPath.(string); ok {
+				if ref, ok := strings.CutPrefix(s, serviceContextMark); ok {
+					contexts[key] = types.ServicePrefix + ref
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/mocks"
+)
+
+func TestLoadServiceBuildContexts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(ctrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compose.yaml")
+	err := os.WriteFile(file, []byte(`
+name: demo
+services:
+  base:
+    build: .
+  app:
+    build:
+      context: .
+      additional_contexts:
+        base: service:base
+        src: ./src
+  tools:
+    build:
+      context: .
+      additional_contexts:
+        - base=service:${BASE:-base}
+`), 0o644)
+	assert.NilError(t, err)
+	opts := ProjectOptions{ConfigPaths: []string{file}, Offline: true}
+
+	project, _, err := opts.ToProject(context.Background(), cli, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, project.Services["app"].Build.AdditionalContexts["base"], "service:base")
+	assert.Equal(t, project.Services["app"].Build.AdditionalContexts["src"], filepath.Join(dir, "src"))
+	assert.Equal(t, project.Services["tools"].Build.AdditionalContexts["base"], "service:base")
+
+	model, err := opts.ToModel(context.Background(), cli, nil)
+	assert.NilError(t, err)
+	app := model["services"].(map[string]any)["app"].(map[string]any)
+	assert.Equal(t, app["build"].(map[string]any)["additional_contexts"].(map[string]any)["base"], "service:base")
+}
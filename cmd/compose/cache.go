@@ -0,0 +1,178 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/go-units"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/cmd/formatter"
+	"github.com/docker/compose/v2/pkg/prompt"
+	"github.com/docker/compose/v2/pkg/remote"
+	"github.com/docker/compose/v2/pkg/utils"
+)
+
+// cacheCommand groups the commands managing the cache of remote resources, like git or OCI hosted Compose files
+func cacheCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache [COMMAND]",
+		Short: "Manage the cache of remote Compose resources",
+	}
+	cmd.AddCommand(
+		cacheListCommand(dockerCli),
+		cachePruneCommand(dockerCli),
+		cacheRefreshCommand(dockerCli),
+	)
+	return cmd
+}
+
+type cacheListOptions struct {
+	format string
+	quiet  bool
+}
+
+func cacheListCommand(dockerCli command.Cli) *cobra.Command {
+	opts := cacheListOptions{}
+	cmd := &cobra.Command{
+		Use:   "ls [OPTIONS]",
+		Short: "List cached remote resources",
+		Args:  cobra.NoArgs,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runCacheList(dockerCli, opts)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	cmd.Flags().StringVar(&opts.format, "format", "table", "Format the output. Values: [table | json]")
+	cmd.Flags().BoolVarP(&opts.quiet, "quiet", "q", false, "Only display digests")
+	return cmd
+}
+
+func runCacheList(dockerCli command.Cli, opts cacheListOptions) error {
+	entries, err := remote.ListCache()
+	if err != nil {
+		return err
+	}
+	if opts.quiet {
+		for _, entry := range entries {
+			_, _ = fmt.Fprintln(dockerCli.Out(), entry.Digest)
+		}
+		return nil
+	}
+	view := viewFromCacheEntries(entries)
+	return formatter.Print(view, opts.format, dockerCli.Out(), func(w io.Writer) {
+		for _, entry := range view {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Type, entry.Reference, entry.Digest, entry.Size, entry.LastUsed)
+		}
+	}, "TYPE", "REFERENCE", "DIGEST", "SIZE", "LAST USED")
+}
+
+type cacheEntryView struct {
+	Type      string
+	Reference string
+	Digest    string
+	Size      string
+	LastUsed  string
+}
+
+func viewFromCacheEntries(entries []remote.CacheEntry) []cacheEntryView {
+	view := make([]cacheEntryView, len(entries))
+	for i, entry := range entries {
+		view[i] = cacheEntryView{
+			Type:      utils.StringOrDash(entry.Type),
+			Reference: utils.StringOrDash(entry.Reference),
+			Digest:    entry.Digest,
+			Size:      units.HumanSizeWithPrecision(float64(entry.Size), 3),
+			LastUsed:  "-",
+		}
+		if !entry.LastUsed.IsZero() {
+			view[i].LastUsed = units.HumanDuration(time.Since(entry.LastUsed)) + " ago"
+		}
+	}
+	return view
+}
+
+type cachePruneOptions struct {
+	unusedFor time.Duration
+	force     bool
+}
+
+func cachePruneCommand(dockerCli command.Cli) *cobra.Command {
+	opts := cachePruneOptions{}
+	cmd := &cobra.Command{
+		Use:   "prune [OPTIONS]",
+		Short: "Remove cached remote resources",
+		Args:  cobra.NoArgs,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runCachePrune(dockerCli, opts)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	cmd.Flags().DurationVar(&opts.unusedFor, "unused-for", 0, "Only remove resources which have not been used for this duration")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "Don't prompt for confirmation")
+	return cmd
+}
+
+func runCachePrune(dockerCli command.Cli, opts cachePruneOptions) error {
+	if !opts.force {
+		msg := "This will remove all cached remote resources. Are you sure you want to continue?"
+		if opts.unusedFor > 0 {
+			msg = fmt.Sprintf("This will remove cached remote resources not used for %s. Are you sure you want to continue?", opts.unusedFor)
+		}
+		confirm, err := prompt.NewPrompt(dockerCli.In(), dockerCli.Out()).Confirm(msg, false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+	pruned, err := remote.PruneCache(opts.unusedFor)
+	var reclaimed int64
+	for _, entry := range pruned {
+		_, _ = fmt.Fprintf(dockerCli.Out(), "Deleted %s\n", entry.Digest)
+		reclaimed += entry.Size
+	}
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Total reclaimed space: %s\n", units.HumanSize(float64(reclaimed)))
+	return err
+}
+
+func cacheRefreshCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Resolve cached branches and tags again, and fetch the ones which moved",
+		Args:  cobra.NoArgs,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runCacheRefresh(ctx, dockerCli)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	return cmd
+}
+
+func runCacheRefresh(ctx context.Context, dockerCli command.Cli) error {
+	refreshed, err := remote.RefreshCache(ctx, dockerCli)
+	for _, entry := range refreshed {
+		_, _ = fmt.Fprintf(dockerCli.Out(), "%s: %s\n", entry.Reference, entry.Digest)
+	}
+	return err
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -70,8 +71,24 @@ const (
 	ComposeEnvFiles = "COMPOSE_ENV_FILES"
 	// ComposeMenu defines if the navigation menu should be rendered. Can be also set via --menu
 	ComposeMenu = "COMPOSE_MENU"
+	// ComposePullRetries defines how many times a pull failing for a transient reason is retried
+	ComposePullRetries = "COMPOSE_PULL_RETRIES"
+	// ComposePullRetryBackoff defines the delay before retrying a failed pull, doubled for each retry
+	ComposePullRetryBackoff = "COMPOSE_PULL_RETRY_BACKOFF"
+	// ComposePullMirrors defines a comma-separated list of registries to pull images from when their own registry fails
+	ComposePullMirrors = "COMPOSE_PULL_MIRRORS"
+	// ComposeSigningKey defines the private key used by `publish --sign` if --signing-key isn't used
+	ComposeSigningKey = "COMPOSE_SIGNING_KEY"
+	// ComposeProgressReport defines the file the junit and markdown progress reports are written to
+	ComposeProgressReport = "COMPOSE_PROGRESS_REPORT"
+	// ComposeAllowHostCommands allows projects to run commands on the host. Can be also set via --allow-host-commands
+	ComposeAllowHostCommands = "COMPOSE_ALLOW_HOST_COMMANDS"
 )
 
+// trustedProjects is the compose plugin configuration listing the remote projects allowed to run commands on the host,
+// as a comma-separated list of git or oci:// references, or prefixes of them
+const trustedProjects = "trusted-projects"
+
 // rawEnv load a dot env file using docker/cli key=value parser, without attempt to interpolate or evaluate values
 func rawEnv(r io.Reader, filename string, lookup func(key string) (string, bool)) (map[string]string, error) {
 	lines, err := kvfile.ParseFromReader(r, lookup)
@@ -98,6 +115,8 @@ type Backend interface {
 	SetDesktopClient(cli *desktop.Client)
 
 	SetExperiments(experiments *experimental.State)
+
+	AllowHostCommands(allowed bool)
 }
 
 // Command defines a compose CLI command as a func with args
@@ -295,7 +314,17 @@ func (o *ProjectOptions) ToModel(ctx context.Context, dockerCli command.Cli, ser
 		api.Separator = "_"
 	}
 
-	return options.LoadModel(ctx)
+	var model map[string]any
+	if hasProviderSecrets(options, remotes) {
+		model, err = loadModelWithProviderSecrets(ctx, options)
+	} else {
+		model, err = options.LoadModel(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	restoreModelServiceBuildContexts(model)
+	return model, nil
 }
 
 func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, services []string, po ...cli.ProjectOptionsFn) (*types.Project, tracing.Metrics, error) { //nolint:gocyclo
@@ -310,6 +339,13 @@ func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, s
 		return nil, metrics, compose.WrapComposeError(err)
 	}
 
+	var sources []string
+	for _, path := range options.ConfigPaths {
+		if isRemoteResource(remotes, path) {
+			sources = append(sources, path)
+		}
+	}
+
 	options.WithListeners(func(event string, metadata map[string]any) {
 		switch event {
 		case "extends":
@@ -317,15 +353,9 @@ func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, s
 		case "include":
 			paths := metadata["path"].(types.StringList)
 			for _, path := range paths {
-				var isRemote bool
-				for _, r := range remotes {
-					if r.Accept(path) {
-						isRemote = true
-						break
-					}
-				}
-				if isRemote {
+				if isRemoteResource(remotes, path) {
 					metrics.CountIncludesRemote++
+					sources = append(sources, path)
 				} else {
 					metrics.CountIncludesLocal++
 				}
@@ -337,15 +367,36 @@ func (o *ProjectOptions) ToProject(ctx context.Context, dockerCli command.Cli, s
 		api.Separator = "_"
 	}
 
-	project, err := options.LoadProject(ctx)
+	var project *types.Project
+	if hasProviderSecrets(options, remotes) {
+		project, err = loadWithProviderSecrets(ctx, options)
+	} else {
+		project, err = options.LoadProject(ctx)
+	}
 	if err != nil {
 		return nil, metrics, compose.WrapComposeError(err)
 	}
+	restoreServiceBuildContexts(project)
 
 	if project.Name == "" {
 		return nil, metrics, errors.New("project name can't be empty. Use `--project-name` to set a valid name")
 	}
 
+	pulled, err := remote.ReadProjectSource(project.WorkingDir)
+	if err != nil {
+		return nil, metrics, err
+	}
+	if pulled != nil {
+		sources = append(sources, pulled.Reference)
+	}
+	delete(project.Extensions, api.UntrustedSourceExtension)
+	if source, ok := untrustedSource(dockerCli, sources); ok {
+		if project.Extensions == nil {
+			project.Extensions = types.Extensions{}
+		}
+		project.Extensions[api.UntrustedSourceExtension] = source
+	}
+
 	project, err = project.WithServicesEnabled(services...)
 	if err != nil {
 		return nil, metrics, err
@@ -386,6 +437,45 @@ func (o *ProjectOptions) remoteLoaders(dockerCli command.Cli) []loader.ResourceL
 	return []loader.ResourceLoader{git, oci}
 }
 
+// untrustedSource returns the first remote source a project is loaded from which isn't listed in the trusted-projects
+// compose plugin configuration
+func untrustedSource(dockerCli command.Cli, sources []string) (string, bool) {
+	var trusted []string
+	for _, ref := range strings.Split(dockerCli.ConfigFile().Plugins["compose"][trustedProjects], ",") {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			trusted = append(trusted, ref)
+		}
+	}
+	for _, source := range sources {
+		if !slices.ContainsFunc(trusted, func(ref string) bool {
+			return trustedBy(source, ref)
+		}) {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// trustedBy returns true if source is the trusted reference, or is within it: a trusted repository also trusts its
+// sub-paths, tags, digests and git refs, but not another repository which name starts the same
+func trustedBy(source string, ref string) bool {
+	rest, ok := strings.CutPrefix(source, ref)
+	if !ok {
+		return false
+	}
+	return rest == "" || strings.HasSuffix(ref, "/") || strings.ContainsAny(rest[:1], "/#@:")
+}
+
+// isRemoteResource returns true when path is loaded by one of the remote resource loaders
+func isRemoteResource(remotes []loader.ResourceLoader, path string) bool {
+	for _, r := range remotes {
+		if r.Accept(path) {
+			return true
+		}
+	}
+	return false
+}
+
 func (o *ProjectOptions) toProjectOptions(po ...cli.ProjectOptionsFn) (*cli.ProjectOptions, error) {
 	return cli.NewProjectOptions(o.ConfigPaths,
 		append(po,
@@ -405,6 +495,8 @@ func (o *ProjectOptions) toProjectOptions(po ...cli.ProjectOptionsFn) (*cli.Proj
 			cli.WithDotEnv,
 			// eventually COMPOSE_PROFILES should have been set
 			cli.WithDefaultProfiles(o.Profiles...),
+			// keep the additional build contexts referring to a service from being resolved as paths
+			cli.WithLoadOptions(withServiceBuildContexts),
 			cli.WithName(o.ProjectName))...)
 }
 
@@ -431,12 +523,13 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 	experiments := experimental.NewState()
 	opts := ProjectOptions{}
 	var (
-		ansi     string
-		noAnsi   bool
-		verbose  bool
-		version  bool
-		parallel int
-		dryRun   bool
+		ansi         string
+		noAnsi       bool
+		verbose      bool
+		version      bool
+		parallel     int
+		dryRun       bool
+		hostCommands bool
 	)
 	c := &cobra.Command{
 		Short:            "Docker Compose",
@@ -475,6 +568,8 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 				logrus.SetLevel(logrus.TraceLevel)
 			}
 
+			// read before .env files are loaded, so a project can't allow itself to run commands on the host
+			allowHostCommands, allowHostCommandsSet := os.LookupEnv(ComposeAllowHostCommands)
 			err := setEnvWithDotEnv(opts)
 			if err != nil {
 				return err
@@ -524,6 +619,9 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 			case ui.ModeJSON:
 				ui.Mode = ui.ModeJSON
 				logrus.SetFormatter(&logrus.JSONFormatter{})
+			case ui.ModeJUnit, ui.ModeMarkdown:
+				ui.Mode = opts.Progress
+				ui.ReportFile = os.Getenv(ComposeProgressReport)
 			default:
 				return fmt.Errorf("unsupported --progress value %q", opts.Progress)
 			}
@@ -569,6 +667,14 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 				backend.MaxConcurrency(parallel)
 			}
 
+			if allowHostCommandsSet && !composeCmd.Flags().Changed("allow-host-commands") {
+				hostCommands, err = strconv.ParseBool(allowHostCommands)
+				if err != nil {
+					return fmt.Errorf("%s must be a boolean (found: %q)", ComposeAllowHostCommands, allowHostCommands)
+				}
+			}
+			backend.AllowHostCommands(hostCommands)
+
 			// dry run detection
 			ctx, err = backend.DryRunMode(ctx, dryRun)
 			if err != nil {
@@ -614,9 +720,14 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 		killCommand(&opts, dockerCli, backend),
 		runCommand(&opts, dockerCli, backend),
 		removeCommand(&opts, dockerCli, backend),
+		pruneCommand(&opts, dockerCli, backend),
 		execCommand(&opts, dockerCli, backend),
 		attachCommand(&opts, dockerCli, backend),
 		exportCommand(&opts, dockerCli, backend),
+		saveCommand(&opts, dockerCli, backend),
+		loadCommand(dockerCli, backend),
+		cacheCommand(dockerCli),
+		secretsCommand(&opts, dockerCli, backend),
 		pauseCommand(&opts, dockerCli, backend),
 		unpauseCommand(&opts, dockerCli, backend),
 		topCommand(&opts, dockerCli, backend),
@@ -663,6 +774,7 @@ func RootCommand(dockerCli command.Cli, backend Backend) *cobra.Command { //noli
 	c.Flags().IntVar(&parallel, "parallel", -1, `Control max parallelism, -1 for unlimited`)
 	c.Flags().BoolVarP(&version, "version", "v", false, "Show the Docker Compose version information")
 	c.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Execute command in dry run mode")
+	c.Flags().BoolVar(&hostCommands, "allow-host-commands", false, "Allow the project to run commands on the host, with x-hooks or command secret providers")
 	c.Flags().MarkHidden("version") //nolint:errcheck
 	c.Flags().BoolVar(&noAnsi, "no-ansi", false, `Do not print ANSI control characters (DEPRECATED)`)
 	c.Flags().MarkHidden("no-ansi") //nolint:errcheck
@@ -700,6 +812,8 @@ var printerModes = []string{
 	ui.ModeTTY,
 	ui.ModePlain,
 	ui.ModeJSON,
+	ui.ModeJUnit,
+	ui.ModeMarkdown,
 	ui.ModeQuiet,
 }
 
@@ -20,7 +20,11 @@ import (
 	"testing"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/config/configfile"
+	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/mocks"
 )
 
 func TestFilterServices(t *testing.T) {
@@ -53,3 +57,49 @@ func TestFilterServices(t *testing.T) {
 	_, err = p.GetService("zot")
 	assert.NilError(t, err)
 }
+
+func TestUntrustedSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(ctrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{
+		Plugins: map[string]map[string]string{
+			"compose": {"trusted-projects": "oci://registry.example.com/team/, https://github.com/docker/awesome-compose.git"},
+		},
+	}).AnyTimes()
+
+	_, untrusted := untrustedSource(cli, nil)
+	assert.Check(t, !untrusted)
+	_, untrusted = untrustedSource(cli, []string{"oci://registry.example.com/team/app:v1", "https://github.com/docker/awesome-compose.git#main"})
+	assert.Check(t, !untrusted)
+	source, untrusted := untrustedSource(cli, []string{"oci://registry.example.com/team/app:v1", "oci://registry.example.com/other/app"})
+	assert.Check(t, untrusted)
+	assert.Equal(t, source, "oci://registry.example.com/other/app")
+}
+
+func TestUntrustedSourceSharingPrefix(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(ctrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{
+		Plugins: map[string]map[string]string{
+			"compose": {"trusted-projects": "oci://registry.example.com/team/app, https://github.com/docker/awesome-compose"},
+		},
+	}).AnyTimes()
+
+	for _, source := range []string{
+		"oci://registry.example.com/team/app",
+		"oci://registry.example.com/team/app:v1",
+		"oci://registry.example.com/team/app@sha256:1bb2b0d6a0a9b2c4e6e3e9b3c2c0a8b1d0d5f4a6e7c8b9a0f1e2d3c4b5a69788",
+		"https://github.com/docker/awesome-compose#main",
+		"https://github.com/docker/awesome-compose/nginx-golang",
+	} {
+		_, untrusted := untrustedSource(cli, []string{source})
+		assert.Check(t, !untrusted, source)
+	}
+	for _, source := range []string{
+		"oci://registry.example.com/team/app-evil",
+		"https://github.com/docker/awesome-compose-evil.git",
+	} {
+		_, untrusted := untrustedSource(cli, []string{source})
+		assert.Check(t, untrusted, source)
+	}
+}
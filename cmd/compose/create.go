@@ -32,20 +32,21 @@ import (
 )
 
 type createOptions struct {
-	Build         bool
-	noBuild       bool
-	Pull          string
-	pullChanged   bool
-	removeOrphans bool
-	ignoreOrphans bool
-	forceRecreate bool
-	noRecreate    bool
-	recreateDeps  bool
-	noInherit     bool
-	timeChanged   bool
-	timeout       int
-	quietPull     bool
-	scale         []string
+	Build           bool
+	noBuild         bool
+	Pull            string
+	pullChanged     bool
+	removeOrphans   bool
+	ignoreOrphans   bool
+	forceRecreate   bool
+	noRecreate      bool
+	recreateDeps    bool
+	noInherit       bool
+	timeChanged     bool
+	timeout         int
+	quietPull       bool
+	pullConcurrency int
+	scale           []string
 }
 
 func createCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
@@ -76,6 +77,7 @@ func createCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service
 	flags.BoolVar(&opts.noBuild, "no-build", false, "Don't build an image, even if it's policy")
 	flags.StringVar(&opts.Pull, "pull", "policy", `Pull image before running ("always"|"missing"|"never"|"build")`)
 	flags.BoolVar(&opts.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.IntVar(&opts.pullConcurrency, "pull-concurrency", 0, "Maximum number of images pulled at the same time. Defaults to --parallel")
 	flags.BoolVar(&opts.forceRecreate, "force-recreate", false, "Recreate containers even if their configuration and image haven't changed")
 	flags.BoolVar(&opts.noRecreate, "no-recreate", false, "If containers already exist, don't recreate them. Incompatible with --force-recreate.")
 	flags.BoolVar(&opts.removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
@@ -97,6 +99,11 @@ func runCreate(ctx context.Context, _ command.Cli, backend api.Service, createOp
 		build = &bo
 	}
 
+	pullRetry, err := pullRetryPolicy(project.Environment)
+	if err != nil {
+		return err
+	}
+
 	return backend.Create(ctx, project, api.CreateOptions{
 		Build:                build,
 		Services:             services,
@@ -107,6 +114,8 @@ func runCreate(ctx context.Context, _ command.Cli, backend api.Service, createOp
 		Inherit:              !createOpts.noInherit,
 		Timeout:              createOpts.GetTimeout(),
 		QuietPull:            createOpts.quietPull,
+		PullRetry:            pullRetry,
+		PullConcurrency:      createOpts.pullConcurrency,
 	})
 }
 
@@ -0,0 +1,55 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type loadOptions struct {
+	input string
+	model string
+}
+
+func loadCommand(dockerCli command.Cli, backend api.Service) *cobra.Command {
+	opts := loadOptions{}
+	cmd := &cobra.Command{
+		Use:   "load [OPTIONS]",
+		Short: "Load the images of a project from a tar archive created by save",
+		Args:  cobra.NoArgs,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runLoad(ctx, backend, opts)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.input, "input", "i", "", "Read from a file, instead of STDIN")
+	flags.StringVar(&opts.model, "model", "", "Write the Compose model included in the archive to this file")
+	return cmd
+}
+
+func runLoad(ctx context.Context, backend api.Service, opts loadOptions) error {
+	return backend.Load(ctx, api.LoadOptions{
+		Input: opts.input,
+		Model: opts.model,
+	})
+}
@@ -0,0 +1,181 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+// hasProviderSecrets returns true if the compose files of a project declare secrets read with an x-provider. These
+// secrets have no file or environment as compose-go requires unless secrets are external, so the project has to be
+// loaded by loadWithProviderSecrets. Only local compose files are checked, so remote ones are not fetched twice
+func hasProviderSecrets(options *cli.ProjectOptions, remotes []loader.ResourceLoader) bool {
+	for _, path := range options.ConfigPaths {
+		if path == "-" || isRemoteResource(remotes, path) {
+			return false
+		}
+	}
+	for _, path := range options.ConfigPaths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// let the loader report the error
+			return false
+		}
+		model, err := loader.ParseYAML(content)
+		if err != nil {
+			return false
+		}
+		secrets, _ := model["secrets"].(map[string]any)
+		for _, value := range secrets {
+			if secret, ok := value.(map[string]any); ok && secret[api.SecretProviderExtension] != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// providerSecretsModel is the model of a project declaring secrets read with an x-provider, loaded once without
+// validation, in which these secrets are marked external so the project is validated and loaded from the model
+type providerSecretsModel struct {
+	details types.ConfigDetails
+	files   []string
+	options loader.Options
+	secrets []string
+}
+
+func loadProviderSecretsModel(ctx context.Context, options *cli.ProjectOptions) (*providerSecretsModel, error) {
+	var loadOptions *loader.Options
+	err := cli.WithLoadOptions(loader.WithSkipValidation, func(opts *loader.Options) {
+		loadOptions = opts
+	})(options)
+	if err != nil {
+		return nil, err
+	}
+	model, err := options.LoadModel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	secrets := markProviderSecretsExternal(model)
+
+	workingDir, err := options.GetWorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, path := range options.ConfigPaths {
+		file, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	// the model has already been interpolated, and only has to be validated
+	loadOptions.SkipValidation = false
+	loadOptions.SkipInterpolation = true
+	// the local resource loader is set again for the working directory
+	loadOptions.ResourceLoaders = loadOptions.RemoteResourceLoaders()
+	return &providerSecretsModel{
+		details: types.ConfigDetails{
+			WorkingDir:  workingDir,
+			ConfigFiles: []types.ConfigFile{{Filename: files[0], Config: model}},
+			Environment: options.Environment,
+		},
+		files:   files,
+		options: *loadOptions,
+		secrets: secrets,
+	}, nil
+}
+
+// markProviderSecretsExternal sets the secrets read with an x-provider which have no file or environment as external,
+// and returns their names
+func markProviderSecretsExternal(model map[string]any) []string {
+	secrets, _ := model["secrets"].(map[string]any)
+	var marked []string
+	for name, value := range secrets {
+		secret, ok := value.(map[string]any)
+		if !ok || secret[api.SecretProviderExtension] == nil {
+			continue
+		}
+		if _, ok := secret["file"]; ok {
+			continue
+		}
+		if _, ok := secret["environment"]; ok {
+			continue
+		}
+		if _, ok := secret["external"]; ok {
+			continue
+		}
+		secret = maps.Clone(secret)
+		secret["external"] = true
+		secrets[name] = secret
+		marked = append(marked, name)
+	}
+	return marked
+}
+
+func (m *providerSecretsModel) withOptions(opts *loader.Options) {
+	*opts = m.options
+}
+
+// loadWithProviderSecrets loads a project declaring secrets read with an x-provider
+func loadWithProviderSecrets(ctx context.Context, options *cli.ProjectOptions) (*types.Project, error) {
+	m, err := loadProviderSecretsModel(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	project, err := loader.LoadWithContext(ctx, m.details, m.withOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range m.secrets {
+		secret := project.Secrets[name]
+		secret.External = false
+		project.Secrets[name] = secret
+	}
+	project.ComposeFiles = m.files
+	return project, nil
+}
+
+// loadModelWithProviderSecrets loads the model of a project declaring secrets read with an x-provider
+func loadModelWithProviderSecrets(ctx context.Context, options *cli.ProjectOptions) (map[string]any, error) {
+	m, err := loadProviderSecretsModel(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	model, err := loader.LoadModelWithContext(ctx, m.details, m.withOptions)
+	if err != nil {
+		return nil, err
+	}
+	secrets, _ := model["secrets"].(map[string]any)
+	for _, name := range m.secrets {
+		if secret, ok := secrets[name].(map[string]any); ok {
+			delete(secret, "external")
+		}
+	}
+	return model, nil
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/mocks"
+)
+
+func TestLoadProviderSecrets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(ctrl)
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "compose.yaml")
+	err := os.WriteFile(file, []byte(`
+name: demo
+services:
+  app:
+    image: alpine
+    command: echo $$HOME
+    secrets: [db]
+secrets:
+  db:
+    x-provider: "command:pass show db"
+`), 0o644)
+	assert.NilError(t, err)
+	opts := ProjectOptions{ConfigPaths: []string{file}, Offline: true}
+
+	project, _, err := opts.ToProject(context.Background(), cli, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, project.ComposeFiles, []string{file})
+	assert.DeepEqual(t, []string(project.Services["app"].Command), []string{"echo", "$HOME"})
+	assert.Check(t, !bool(project.Secrets["db"].External))
+	assert.Equal(t, project.Secrets["db"].Extensions[api.SecretProviderExtension], "command:pass show db")
+
+	model, err := opts.ToModel(context.Background(), cli, nil)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, model["secrets"], map[string]any{
+		"db": map[string]any{"name": "demo_db", "x-provider": "command:pass show db"},
+	})
+
+	err = os.WriteFile(file, []byte(`
+name: demo
+services:
+  app:
+    image: alpine
+    secrets: [db, missing]
+secrets:
+  db:
+    x-provider: "command:pass show db"
+`), 0o644)
+	assert.NilError(t, err)
+	_, _, err = opts.ToProject(context.Background(), cli, nil)
+	assert.ErrorContains(t, err, "undefined secret missing")
+
+	err = os.WriteFile(file, []byte(`
+name: demo
+services:
+  app:
+    image: alpine
+    unknown: true
+    secrets: [db]
+secrets:
+  db:
+    x-provider: "command:pass show db"
+`), 0o644)
+	assert.NilError(t, err)
+	// the validation error of the project is reported as is
+	_, _, err = opts.ToProject(context.Background(), cli, nil)
+	assert.ErrorContains(t, err, "services.app Additional property unknown is not allowed")
+}
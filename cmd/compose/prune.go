@@ -0,0 +1,75 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type pruneOptions struct {
+	*ProjectOptions
+	oneOff    bool
+	olderThan time.Duration
+	force     bool
+}
+
+func pruneCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
+	opts := pruneOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "prune [OPTIONS]",
+		Short: "Remove stale resources of the project",
+		Long: `Remove stale resources of the project
+
+With --oneoff, removes the one-off containers created by "docker compose run"
+which are stopped, either because they were run without --rm or because the run
+crashed, along with their anonymous volumes. Running containers are never removed.`,
+		Args: cobra.NoArgs,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runPrune(ctx, dockerCli, backend, opts)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	f := cmd.Flags()
+	f.BoolVar(&opts.oneOff, "oneoff", false, "Remove the stopped one-off containers created by run, and their anonymous volumes")
+	f.DurationVar(&opts.olderThan, "older-than", 0, "Only remove the containers stopped for longer than this duration")
+	f.BoolVarP(&opts.force, "force", "f", false, "Don't ask to confirm removal")
+	return cmd
+}
+
+func runPrune(ctx context.Context, dockerCli command.Cli, backend api.Service, opts pruneOptions) error {
+	if !opts.oneOff {
+		return errors.New("nothing to prune, select the resources to remove with --oneoff")
+	}
+	name, err := opts.toProjectName(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	return backend.Prune(ctx, name, api.PruneOptions{
+		OneOff:    opts.oneOff,
+		OlderThan: opts.olderThan,
+		Force:     opts.force,
+	})
+}
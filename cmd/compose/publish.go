@@ -18,7 +18,12 @@ package compose
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/loader"
+	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/cli/cli/command"
 	"github.com/spf13/cobra"
 
@@ -29,6 +34,9 @@ type publishOptions struct {
 	*ProjectOptions
 	resolveImageDigests bool
 	ociVersion          string
+	assumeYes           bool
+	sign                bool
+	signingKey          string
 }
 
 func publishCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
@@ -46,17 +54,53 @@ func publishCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Servic
 	flags := cmd.Flags()
 	flags.BoolVar(&opts.resolveImageDigests, "resolve-image-digests", false, "Pin image tags to digests")
 	flags.StringVar(&opts.ociVersion, "oci-version", "", "OCI Image/Artifact specification version (automatically determined by default)")
+	flags.BoolVar(&opts.sign, "sign", false, "Sign the published artifact")
+	flags.StringVar(&opts.signingKey, "signing-key", "", "PEM encoded private key to sign the artifact with. Defaults to COMPOSE_SIGNING_KEY")
+	flags.BoolVarP(&opts.assumeYes, "yes", "y", false, `Assume "yes" as answer to all prompts, including publishing files which look like they contain secrets`)
 	return cmd
 }
 
 func runPublish(ctx context.Context, dockerCli command.Cli, backend api.Service, opts publishOptions, repository string) error {
-	project, _, err := opts.ToProject(ctx, dockerCli, nil)
+	remotes := opts.remoteLoaders(dockerCli)
+	var includes []string
+	project, _, err := opts.ToProject(ctx, dockerCli, nil, cli.WithLoadOptions(func(o *loader.Options) {
+		o.Listeners = append(o.Listeners, func(event string, metadata map[string]any) {
+			if event != "include" {
+				return
+			}
+			workingDir, _ := metadata["workingdir"].(string)
+			paths, _ := metadata["path"].(types.StringList)
+			for _, path := range paths {
+				if isRemoteResource(remotes, path) {
+					continue
+				}
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(workingDir, path)
+				}
+				includes = append(includes, path)
+			}
+		})
+	}))
 	if err != nil {
 		return err
 	}
 
+	var signingKey string
+	if opts.sign {
+		signingKey = opts.signingKey
+		if signingKey == "" {
+			signingKey = project.Environment[ComposeSigningKey]
+		}
+		if signingKey == "" {
+			return fmt.Errorf("--sign requires a signing key, set with --signing-key or %s", ComposeSigningKey)
+		}
+	}
+
 	return backend.Publish(ctx, project, repository, api.PublishOptions{
 		ResolveImageDigests: opts.resolveImageDigests,
 		OCIVersion:          api.OCIVersion(opts.ociVersion),
+		Includes:            includes,
+		AssumeYes:           opts.assumeYes,
+		SigningKey:          signingKey,
 	})
 }
@@ -20,6 +20,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/docker/cli/cli/command"
@@ -39,6 +42,11 @@ type pullOptions struct {
 	ignorePullFailures bool
 	noBuildable        bool
 	policy             string
+	retries            int
+	retriesChanged     bool
+	retryBackoff       time.Duration
+	mirrors            []string
+	concurrency        int
 }
 
 func pullCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
@@ -54,7 +62,8 @@ func pullCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service)
 			}
 			return nil
 		}),
-		RunE: Adapt(func(ctx context.Context, args []string) error {
+		RunE: AdaptCmd(func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			opts.retriesChanged = cmd.Flags().Changed("retries")
 			return runPull(ctx, dockerCli, backend, opts, args)
 		}),
 		ValidArgsFunction: completeServiceNames(dockerCli, p),
@@ -69,6 +78,10 @@ func pullCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service)
 	cmd.Flags().BoolVar(&opts.ignorePullFailures, "ignore-pull-failures", false, "Pull what it can and ignores images with pull failures")
 	cmd.Flags().BoolVar(&opts.noBuildable, "ignore-buildable", false, "Ignore images that can be built")
 	cmd.Flags().StringVar(&opts.policy, "policy", "", `Apply pull policy ("missing"|"always")`)
+	cmd.Flags().IntVar(&opts.concurrency, "pull-concurrency", 0, "Maximum number of images pulled at the same time. Defaults to --parallel")
+	cmd.Flags().IntVar(&opts.retries, "retries", 0, fmt.Sprintf("Retry pulls failing for a transient reason, with exponential backoff. Defaults to %s", ComposePullRetries))
+	cmd.Flags().DurationVar(&opts.retryBackoff, "retry-backoff", 0, fmt.Sprintf("Delay before the first retry, doubled for each retry. Defaults to %s or 1s", ComposePullRetryBackoff))
+	cmd.Flags().StringArrayVar(&opts.mirrors, "mirror", nil, fmt.Sprintf("Registry mirror to pull images from when their registry is unavailable, tried in order. Defaults to %s", ComposePullMirrors))
 	return cmd
 }
 
@@ -104,9 +117,50 @@ func runPull(ctx context.Context, dockerCli command.Cli, backend api.Service, op
 		return err
 	}
 
+	retry, err := pullRetryPolicy(project.Environment)
+	if err != nil {
+		return err
+	}
+	if opts.retriesChanged {
+		retry.Attempts = opts.retries + 1
+	}
+	if opts.retryBackoff != 0 {
+		retry.Backoff = opts.retryBackoff
+	}
+	if len(opts.mirrors) > 0 {
+		retry.Mirrors = opts.mirrors
+	}
+
 	return backend.Pull(ctx, project, api.PullOptions{
 		Quiet:           opts.quiet,
 		IgnoreFailures:  opts.ignorePullFailures,
 		IgnoreBuildable: opts.noBuildable,
+		Retry:           retry,
+		Concurrency:     opts.concurrency,
 	})
 }
+
+// pullRetryPolicy returns the pull retry policy set by the project environment
+func pullRetryPolicy(env types.Mapping) (api.PullRetryPolicy, error) {
+	var policy api.PullRetryPolicy
+	if v := env[ComposePullRetries]; v != "" {
+		retries, err := strconv.Atoi(v)
+		if err != nil || retries < 0 {
+			return policy, fmt.Errorf("%s must be a positive number, got %q", ComposePullRetries, v)
+		}
+		policy.Attempts = retries + 1
+	}
+	if v := env[ComposePullRetryBackoff]; v != "" {
+		backoff, err := time.ParseDuration(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid %s: %w", ComposePullRetryBackoff, err)
+		}
+		policy.Backoff = backoff
+	}
+	for _, mirror := range strings.Split(env[ComposePullMirrors], ",") {
+		if mirror = strings.TrimSpace(mirror); mirror != "" {
+			policy.Mirrors = append(policy.Mirrors, mirror)
+		}
+	}
+	return policy, nil
+}
@@ -18,9 +18,12 @@ package compose
 
 import (
 	"testing"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
 )
 
 func TestApplyPullOptions(t *testing.T) {
@@ -55,3 +58,24 @@ func TestApplyPullOptions(t *testing.T) {
 	assert.Equal(t, project.Services["has-build"].PullPolicy, types.PullPolicyMissing)
 	assert.Equal(t, project.Services["must-pull"].PullPolicy, types.PullPolicyMissing)
 }
+
+func TestPullRetryPolicy(t *testing.T) {
+	policy, err := pullRetryPolicy(types.Mapping{
+		ComposePullRetries:      "3",
+		ComposePullRetryBackoff: "500ms",
+		ComposePullMirrors:      "mirror.gcr.io, registry.local:5000/hub,",
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy, api.PullRetryPolicy{
+		Attempts: 4,
+		Backoff:  500 * time.Millisecond,
+		Mirrors:  []string{"mirror.gcr.io", "registry.local:5000/hub"},
+	})
+
+	policy, err = pullRetryPolicy(types.Mapping{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, policy, api.PullRetryPolicy{})
+
+	_, err = pullRetryPolicy(types.Mapping{ComposePullRetries: "-1"})
+	assert.ErrorContains(t, err, "COMPOSE_PULL_RETRIES must be a positive number")
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/pkg/remote"
+)
+
+func pullProjectCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pull-project REFERENCE [DIRECTORY]",
+		Short: "Write the files of a project published to a registry or hosted in a git repository into a local directory",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			dir := defaultProjectDir(args[0])
+			if len(args) > 1 {
+				dir = args[1]
+			}
+			return runPullProject(ctx, dockerCli, args[0], dir)
+		}),
+		ValidArgsFunction: noCompletion(),
+	}
+	return cmd
+}
+
+func runPullProject(ctx context.Context, dockerCli command.Cli, reference string, dir string) error {
+	source, err := remote.PullProject(ctx, dockerCli, reference, dir)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(dockerCli.Out(), "Pulled %s (%s) into %s\n", source.Reference, source.Digest, dir)
+	return nil
+}
+
+// defaultProjectDir returns the directory to pull a project into when not set, named after the repository
+func defaultProjectDir(reference string) string {
+	name := reference[strings.LastIndex(reference, "/")+1:]
+	name, _, _ = strings.Cut(name, "#")
+	name, _, _ = strings.Cut(name, "@")
+	name, _, _ = strings.Cut(name, ":")
+	return strings.TrimSuffix(name, ".git")
+}
@@ -64,6 +64,7 @@ type runOptions struct {
 	noDeps        bool
 	ignoreOrphans bool
 	quietPull     bool
+	gc            bool
 }
 
 func (options runOptions) apply(project *types.Project) (*types.Project, error) {
@@ -188,6 +189,7 @@ func runCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *
 	flags.BoolVar(&options.useAliases, "use-aliases", false, "Use the service's network useAliases in the network(s) the container connects to")
 	flags.BoolVarP(&options.servicePorts, "service-ports", "P", false, "Run command with all service's ports enabled and mapped to the host")
 	flags.BoolVar(&options.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.BoolVar(&options.gc, "gc", false, "Remove the one-off containers of the project stopped for more than a minute, and their anonymous volumes, before running")
 	flags.BoolVar(&createOpts.Build, "build", false, "Build image before starting container")
 	flags.BoolVar(&createOpts.removeOrphans, "remove-orphans", false, "Remove containers for services not defined in the Compose file")
 
@@ -221,6 +223,11 @@ func runRun(ctx context.Context, backend api.Service, project *types.Project, op
 		return err
 	}
 
+	pullRetry, err := pullRetryPolicy(project.Environment)
+	if err != nil {
+		return err
+	}
+
 	err = progress.Run(ctx, func(ctx context.Context) error {
 		var buildForDeps *api.BuildOptions
 		if !createOpts.noBuild {
@@ -231,7 +238,7 @@ func runRun(ctx context.Context, backend api.Service, project *types.Project, op
 			}
 			buildForDeps = &bo
 		}
-		return startDependencies(ctx, backend, *project, buildForDeps, options)
+		return startDependencies(ctx, backend, *project, buildForDeps, pullRetry, options)
 	}, dockerCli.Err())
 	if err != nil {
 		return err
@@ -278,6 +285,8 @@ func runRun(ctx context.Context, backend api.Service, project *types.Project, op
 		NoDeps:            options.noDeps,
 		Index:             0,
 		QuietPull:         options.quietPull,
+		PullRetry:         pullRetry,
+		GC:                options.gc,
 	}
 
 	for name, service := range project.Services {
@@ -298,7 +307,7 @@ func runRun(ctx context.Context, backend api.Service, project *types.Project, op
 	return err
 }
 
-func startDependencies(ctx context.Context, backend api.Service, project types.Project, buildOpts *api.BuildOptions, options runOptions) error {
+func startDependencies(ctx context.Context, backend api.Service, project types.Project, buildOpts *api.BuildOptions, pullRetry api.PullRetryPolicy, options runOptions) error {
 	dependencies := types.Services{}
 	var requestedService types.ServiceConfig
 	for name, service := range project.Services {
@@ -315,6 +324,7 @@ func startDependencies(ctx context.Context, backend api.Service, project types.P
 		Build:         buildOpts,
 		IgnoreOrphans: options.ignoreOrphans,
 		QuietPull:     options.quietPull,
+		PullRetry:     pullRetry,
 	})
 	if err != nil {
 		return err
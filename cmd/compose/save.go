@@ -0,0 +1,73 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+type saveOptions struct {
+	*ProjectOptions
+
+	output    string
+	platforms []string
+	noBuild   bool
+}
+
+func saveCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
+	opts := saveOptions{
+		ProjectOptions: p,
+	}
+	buildOpts := buildOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "save [OPTIONS] [SERVICE...]",
+		Short: "Save the images and the Compose model of a project to a tar archive",
+		RunE: p.WithServices(dockerCli, func(ctx context.Context, project *types.Project, services []string) error {
+			return runSave(ctx, backend, opts, buildOpts, project, services)
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", "Write to a file, instead of STDOUT")
+	flags.StringSliceVar(&opts.platforms, "platform", nil, "Also save the images of services without a build section for these platforms")
+	flags.BoolVar(&opts.noBuild, "no-build", false, "Don't build missing images")
+	return cmd
+}
+
+func runSave(ctx context.Context, backend api.Service, opts saveOptions, buildOpts buildOptions, project *types.Project, services []string) error {
+	var build *api.BuildOptions
+	if !opts.noBuild {
+		bo, err := buildOpts.toAPIBuildOptions(services)
+		if err != nil {
+			return err
+		}
+		build = &bo
+	}
+	return backend.Save(ctx, project, api.SaveOptions{
+		Output:    opts.output,
+		Platforms: opts.platforms,
+		Build:     build,
+	})
+}
@@ -0,0 +1,154 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/cli"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/command"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+// secretsCommand groups the commands managing the secrets and configs of running services
+func secretsCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets [COMMAND]",
+		Short: "Manage the secrets and configs of running services",
+	}
+	cmd.AddCommand(
+		secretsRefreshCommand(p, dockerCli, backend),
+	)
+	return cmd
+}
+
+type secretsRefreshOptions struct {
+	*ProjectOptions
+	noReload bool
+	watch    bool
+	interval time.Duration
+}
+
+func secretsRefreshCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *cobra.Command {
+	opts := secretsRefreshOptions{
+		ProjectOptions: p,
+	}
+	cmd := &cobra.Command{
+		Use:   "refresh [OPTIONS] [SERVICE...]",
+		Short: "Update the secrets and configs set from the environment, a provider or inline in running containers, without recreating them",
+		Long: `Update the secrets and configs set from the environment, a provider or inline in running containers, without recreating them.
+
+Only the files which content changed are copied. Services then reload them as declared by their x-reload extension,
+either by running a command in the container or by sending it a signal.`,
+		RunE: Adapt(func(ctx context.Context, args []string) error {
+			return runSecretsRefresh(ctx, dockerCli, backend, opts, args)
+		}),
+		ValidArgsFunction: completeServiceNames(dockerCli, p),
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.noReload, "no-reload", false, "Don't reload services once their secrets and configs are updated")
+	flags.BoolVar(&opts.watch, "watch", false, "Keep watching the project, and refresh secrets and configs as they change")
+	flags.DurationVar(&opts.interval, "watch-interval", 5*time.Second, "Interval between two checks of the project for changes in watch mode")
+	return cmd
+}
+
+func runSecretsRefresh(ctx context.Context, dockerCli command.Cli, backend api.Service, opts secretsRefreshOptions, services []string) error {
+	var (
+		previous   *types.Project
+		containers []string
+	)
+	for {
+		project, _, err := opts.ToProject(ctx, dockerCli, services, cli.WithResolvedPaths(true), cli.WithDiscardEnvFile)
+		switch {
+		case err != nil && !opts.watch:
+			return err
+		case err != nil:
+			logrus.Warnf("failed to load project, secrets and configs not refreshed: %v", err)
+		default:
+			var current []string
+			if opts.watch {
+				// containers recreated since the last refresh have the secrets and configs of their creation
+				current, err = runningContainers(ctx, backend, project, services)
+				if err != nil {
+					logrus.Warnf("failed to list containers, secrets and configs not refreshed: %v", err)
+					break
+				}
+			}
+			if previous != nil && !secretsChanged(previous, project) && slices.Equal(containers, current) {
+				break
+			}
+			err = backend.RefreshSecrets(ctx, project, api.RefreshSecretsOptions{
+				Services: services,
+				NoReload: opts.noReload,
+			})
+			switch {
+			case err == nil:
+				previous = project
+				containers = current
+			case !opts.watch:
+				return err
+			default:
+				logrus.Warnf("failed to refresh secrets and configs: %v", err)
+			}
+		}
+		if !opts.watch {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// runningContainers returns the sorted IDs of the running containers of the selected services
+func runningContainers(ctx context.Context, backend api.Service, project *types.Project, services []string) ([]string, error) {
+	containers, err := backend.Ps(ctx, project.Name, api.PsOptions{
+		Project:  project,
+		Services: services,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(containers))
+	for _, ctr := range containers {
+		ids = append(ids, ctr.ID)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// secretsChanged tells whether the content of secrets and configs may have changed between two loads of a project.
+// Secrets read with an x-provider can change anytime without the project being modified.
+func secretsChanged(previous, current *types.Project) bool {
+	for _, secret := range current.Secrets {
+		if _, ok := secret.Extensions[api.SecretProviderExtension]; ok {
+			return true
+		}
+	}
+	return !reflect.DeepEqual(previous.Environment, current.Environment) ||
+		!reflect.DeepEqual(previous.Secrets, current.Secrets) ||
+		!reflect.DeepEqual(previous.Configs, current.Configs)
+}
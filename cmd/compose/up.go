@@ -59,6 +59,7 @@ type upOptions struct {
 	watch                 bool
 	navigationMenu        bool
 	navigationMenuChanged bool
+	timings               bool
 }
 
 func (opts upOptions) apply(project *types.Project, services []string) (*types.Project, error) {
@@ -163,12 +164,14 @@ func upCommand(p *ProjectOptions, dockerCli command.Cli, backend api.Service) *c
 	flags.BoolVar(&create.recreateDeps, "always-recreate-deps", false, "Recreate dependent containers. Incompatible with --no-recreate.")
 	flags.BoolVarP(&create.noInherit, "renew-anon-volumes", "V", false, "Recreate anonymous volumes instead of retrieving data from the previous containers")
 	flags.BoolVar(&create.quietPull, "quiet-pull", false, "Pull without printing progress information")
+	flags.IntVar(&create.pullConcurrency, "pull-concurrency", 0, "Maximum number of images pulled at the same time. Defaults to --parallel")
 	flags.StringArrayVar(&up.attach, "attach", []string{}, "Restrict attaching to the specified services. Incompatible with --attach-dependencies.")
 	flags.StringArrayVar(&up.noAttach, "no-attach", []string{}, "Do not attach (stream logs) to the specified services")
 	flags.BoolVar(&up.attachDependencies, "attach-dependencies", false, "Automatically attach to log output of dependent services")
 	flags.BoolVar(&up.wait, "wait", false, "Wait for services to be running|healthy. Implies detached mode.")
 	flags.IntVar(&up.waitTimeout, "wait-timeout", 0, "Maximum duration to wait for the project to be running|healthy")
 	flags.BoolVarP(&up.watch, "watch", "w", false, "Watch source code and rebuild/refresh containers when files are updated.")
+	flags.BoolVar(&up.timings, "timings", false, "Print the time spent by each service in each step once started and healthy, and the critical path through dependencies")
 	flags.BoolVar(&up.navigationMenu, "menu", false, "Enable interactive shortcuts when running attached. Incompatible with --detach. Can also be enable/disable by setting COMPOSE_MENU environment var.")
 
 	return upCmd
@@ -245,6 +248,11 @@ func runUp(
 		build = &bo
 	}
 
+	pullRetry, err := pullRetryPolicy(project.Environment)
+	if err != nil {
+		return err
+	}
+
 	create := api.CreateOptions{
 		Build:                build,
 		Services:             services,
@@ -255,6 +263,8 @@ func runUp(
 		Inherit:              !createOptions.noInherit,
 		Timeout:              createOptions.GetTimeout(),
 		QuietPull:            createOptions.quietPull,
+		PullRetry:            pullRetry,
+		PullConcurrency:      createOptions.pullConcurrency,
 	}
 
 	if upOptions.noStart {
@@ -311,6 +321,7 @@ func runUp(
 			Services:       services,
 			NavigationMenu: upOptions.navigationMenu && ui.Mode != "plain",
 		},
+		Timings: upOptions.timings,
 	})
 }
 
@@ -22,7 +22,13 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/buger/goterm"
@@ -32,6 +38,7 @@ import (
 	"github.com/docker/compose/v2/pkg/watch"
 	"github.com/eiannone/keyboard"
 	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
 	"github.com/skratchdot/open-golang/open"
 )
 
@@ -76,6 +83,15 @@ type KeyboardWatch struct {
 	WatchFn  func(ctx context.Context, doneCh chan bool, project *types.Project, services []string, options api.WatchOptions) error
 	Ctx      context.Context
 	Cancel   context.CancelFunc
+	// Services lists the services the menu can select
+	Services []string
+	// Selected is the index in Services of the service menu actions apply to
+	Selected int
+	// Watched lists the services watch is running for
+	Watched []string
+	// Paused tracks services for which watch has been paused from the menu
+	Paused   map[string]bool
+	Commands chan api.WatchCommand
 }
 
 func (kw *KeyboardWatch) isWatching() bool {
@@ -86,6 +102,43 @@ func (kw *KeyboardWatch) switchWatching() {
 	kw.Watching = !kw.Watching
 }
 
+func (kw *KeyboardWatch) isWatched(service string) bool {
+	return kw.Watching && slices.Contains(kw.Watched, service)
+}
+
+func (kw *KeyboardWatch) selectedService() (string, bool) {
+	if len(kw.Services) == 0 {
+		return "", false
+	}
+	return kw.Services[kw.Selected%len(kw.Services)], true
+}
+
+func (kw *KeyboardWatch) selectNext() {
+	if len(kw.Services) == 0 {
+		return
+	}
+	kw.Selected = (kw.Selected + 1) % len(kw.Services)
+}
+
+// watchedServices returns the services of the project which define a develop section,
+// restricted to the selected ones if any
+func watchedServices(project *types.Project, selected []string) []string {
+	var services []string
+	for name, service := range project.Services {
+		if len(selected) > 0 && !slices.Contains(selected, name) {
+			continue
+		}
+		if service.Build == nil {
+			continue
+		}
+		if _, ok := service.Extensions["x-develop"]; ok || service.Develop != nil {
+			services = append(services, name)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
 func (kw *KeyboardWatch) newContext(ctx context.Context) context.CancelFunc {
 	ctx, cancel := context.WithCancel(ctx)
 	kw.Ctx = ctx
@@ -101,13 +154,55 @@ const (
 	DEBUG KEYBOARD_LOG_LEVEL = 2
 )
 
+// ServiceActions runs the operations the navigation menu offers on a single service
+type ServiceActions interface {
+	Restart(ctx context.Context, service string) error
+	Stop(ctx context.Context, service string) error
+	Start(ctx context.Context, service string) error
+	// Shell runs an interactive shell in the service container, attached to the terminal
+	Shell(ctx context.Context, service string) error
+	Ps(ctx context.Context) ([]api.ContainerSummary, error)
+}
+
+type KeyboardOverlay struct {
+	text      string
+	timeStart time.Time
+}
+
+func (ko *KeyboardOverlay) shouldDisplay() bool {
+	return ko.text != "" && int(time.Since(ko.timeStart).Seconds()) < DISPLAY_ERROR_TIME
+}
+
+func (ko *KeyboardOverlay) lines() []string {
+	return strings.Split(strings.TrimSuffix(ko.text, "\n"), "\n")
+}
+
+// printOverlay prints the overlay lines right above the given line
+func (ko *KeyboardOverlay) printOverlay(above int) {
+	if !ko.shouldDisplay() {
+		return
+	}
+	lines := ko.lines()
+	for i, line := range lines {
+		MoveCursor(above-len(lines)+i, 0)
+		ClearLine()
+		fmt.Print(line)
+	}
+}
+
 type LogKeyboard struct {
 	kError                KeyboardError
+	overlay               KeyboardOverlay
 	Watch                 KeyboardWatch
 	IsDockerDesktopActive bool
 	IsWatchConfigured     bool
 	logLevel              KEYBOARD_LOG_LEVEL
 	signalChannel         chan<- os.Signal
+	// KeyEvents is the channel keyboard events are read from, it changes after the keyboard has been released to a shell
+	KeyEvents <-chan keyboard.KeyEvent
+	actions   ServiceActions
+	mutedLogs sync.Map // map[string]bool
+	shell     atomic.Bool
 }
 
 var KeyboardManager *LogKeyboard
@@ -115,26 +210,50 @@ var eg multierror.Group
 
 func NewKeyboardManager(ctx context.Context, isDockerDesktopActive, isWatchConfigured bool,
 	sc chan<- os.Signal,
+	kEvents <-chan keyboard.KeyEvent,
 	watchFn func(ctx context.Context,
 		doneCh chan bool,
 		project *types.Project,
 		services []string,
 		options api.WatchOptions,
 	) error,
+	services []string,
+	actions ServiceActions,
 ) {
 	km := LogKeyboard{}
 	km.IsDockerDesktopActive = isDockerDesktopActive
 	km.IsWatchConfigured = isWatchConfigured
 	km.logLevel = INFO
+	km.KeyEvents = kEvents
+	km.actions = actions
 
 	km.Watch.Watching = false
+	km.Watch.Services = slices.Clone(services)
+	sort.Strings(km.Watch.Services)
 	km.Watch.WatchFn = watchFn
+	km.Watch.Commands = make(chan api.WatchCommand, 10)
 
 	km.signalChannel = sc
 
 	KeyboardManager = &km
 }
 
+// IsLogMuted tells if logs from the service must not be displayed, either because they have been
+// toggled off from the menu or because a shell currently owns the terminal
+func (lk *LogKeyboard) IsLogMuted(service string) bool {
+	if lk.shell.Load() {
+		return true
+	}
+	_, muted := lk.mutedLogs.Load(service)
+	return muted
+}
+
+func (lk *LogKeyboard) toggleLogs(service string) {
+	if _, muted := lk.mutedLogs.LoadAndDelete(service); !muted {
+		lk.mutedLogs.Store(service, true)
+	}
+}
+
 func (lk *LogKeyboard) ClearKeyboardInfo() {
 	lk.clearNavigationMenu()
 }
@@ -152,6 +271,10 @@ func (lk *LogKeyboard) createBuffer(lines int) {
 		lines += extraLines
 	}
 
+	if lk.overlay.shouldDisplay() {
+		lines += len(lk.overlay.lines())
+	}
+
 	// get the string
 	infoMessage := lk.navigationMenu()
 	// calculate how many lines we need to display the menu info
@@ -179,6 +302,12 @@ func (lk *LogKeyboard) printNavigationMenu() {
 
 		lk.kError.printError(height, menu)
 
+		above := height - 1 - extraLines(menu)
+		if lk.kError.shouldDisplay() {
+			above -= extraLines(lk.kError.error()) + 1
+		}
+		lk.overlay.printOverlay(above)
+
 		MoveCursor(height-extraLines(menu), 0)
 		ClearLine()
 		fmt.Print(menu)
@@ -211,7 +340,49 @@ func (lk *LogKeyboard) navigationMenu() string {
 		isEnabled = " Disable"
 	}
 	watchInfo = watchInfo + shortcutKeyColor("w") + navColor(isEnabled+" Watch")
-	return openDDInfo + openDDUI + watchInfo
+	return openDDInfo + openDDUI + watchInfo + lk.servicesMenu()
+}
+
+// servicesMenu shows the services with their watch state and the actions on the selected one
+func (lk *LogKeyboard) servicesMenu() string {
+	selected, ok := lk.Watch.selectedService()
+	if !ok {
+		return ""
+	}
+	var states []string
+	for _, name := range lk.Watch.Services {
+		state := name
+		if lk.Watch.isWatched(name) && lk.Watch.Paused[name] {
+			state += " (paused)"
+		}
+		if _, muted := lk.mutedLogs.Load(name); muted {
+			state += " (muted)"
+		}
+		if name == selected {
+			states = append(states, ansiColor(BOLD, state))
+		} else {
+			states = append(states, navColor(state))
+		}
+	}
+	menu := navColor("   ") + shortcutKeyColor("⇥") + " " + strings.Join(states, navColor(" | "))
+	if lk.actions != nil {
+		menu += navColor("   ") + shortcutKeyColor("r") + navColor(" Restart") +
+			navColor("   ") + shortcutKeyColor("s") + navColor(" Start") +
+			navColor("   ") + shortcutKeyColor("x") + navColor(" Stop") +
+			navColor("   ") + shortcutKeyColor("e") + navColor(" Shell") +
+			navColor("   ") + shortcutKeyColor("l") + navColor(" Toggle Logs") +
+			navColor("   ") + shortcutKeyColor("c") + navColor(" Containers")
+	}
+	if lk.Watch.isWatched(selected) {
+		pause := " Pause"
+		if lk.Watch.Paused[selected] {
+			pause = " Resume"
+		}
+		menu += navColor("   ") + shortcutKeyColor("p") + navColor(pause) +
+			navColor("   ") + shortcutKeyColor("f") + navColor(" Sync") +
+			navColor("   ") + shortcutKeyColor("b") + navColor(" Rebuild")
+	}
+	return menu
 }
 
 func (lk *LogKeyboard) clearNavigationMenu() {
@@ -293,6 +464,8 @@ func (lk *LogKeyboard) StartWatch(ctx context.Context, doneCh chan bool, project
 	if !lk.Watch.isWatching() {
 		lk.Watch.Cancel()
 	} else {
+		lk.Watch.Watched = watchedServices(project, options.Start.Services)
+		lk.Watch.Paused = map[string]bool{}
 		eg.Go(tracing.EventWrapFuncForErrGroup(ctx, "menu/watch", tracing.SpanOptions{},
 			func(ctx context.Context) error {
 				if options.Create.Build == nil {
@@ -305,13 +478,36 @@ func (lk *LogKeyboard) StartWatch(ctx context.Context, doneCh chan bool, project
 				buildOpts := *options.Create.Build
 				buildOpts.Quiet = true
 				return lk.Watch.WatchFn(lk.Watch.Ctx, doneCh, project, options.Start.Services, api.WatchOptions{
-					Build: &buildOpts,
-					LogTo: options.Start.Attach,
+					Build:    &buildOpts,
+					LogTo:    options.Start.Attach,
+					Commands: lk.Watch.Commands,
 				})
 			}))
 	}
 }
 
+// sendWatchCommand requests running watch to apply action on the selected service
+func (lk *LogKeyboard) sendWatchCommand(action api.WatchCommandAction) {
+	service, _ := lk.Watch.selectedService()
+	if !lk.Watch.isWatched(service) {
+		lk.keyboardError("Watch", fmt.Errorf("watch is not enabled for service %q", service))
+		return
+	}
+	select {
+	case lk.Watch.Commands <- api.WatchCommand{Service: service, Action: action}:
+	default:
+		lk.keyboardError("Watch", fmt.Errorf("too many pending watch commands, try again later"))
+		return
+	}
+	switch action {
+	case api.WatchPause:
+		lk.Watch.Paused[service] = true
+	case api.WatchResume:
+		delete(lk.Watch.Paused, service)
+	}
+	lk.printNavigationMenu()
+}
+
 func (lk *LogKeyboard) HandleKeyEvents(event keyboard.KeyEvent, ctx context.Context, doneCh chan bool, project *types.Project, options api.UpOptions) {
 	switch kRune := event.Rune; kRune {
 	case 'v':
@@ -334,8 +530,24 @@ func (lk *LogKeyboard) HandleKeyEvents(event keyboard.KeyEvent, ctx context.Cont
 		lk.StartWatch(ctx, doneCh, project, options)
 	case 'o':
 		lk.openDDComposeUI(ctx, project)
+	case 'r', 's', 'x', 'e', 'l', 'c':
+		lk.serviceAction(ctx, kRune)
+	case 'p':
+		service, _ := lk.Watch.selectedService()
+		if lk.Watch.Paused[service] {
+			lk.sendWatchCommand(api.WatchResume)
+		} else {
+			lk.sendWatchCommand(api.WatchPause)
+		}
+	case 'f':
+		lk.sendWatchCommand(api.WatchSync)
+	case 'b':
+		lk.sendWatchCommand(api.WatchRebuild)
 	}
 	switch key := event.Key; key {
+	case keyboard.KeyTab:
+		lk.Watch.selectNext()
+		lk.printNavigationMenu()
 	case keyboard.KeyCtrlC:
 		_ = keyboard.Close()
 		lk.clearNavigationMenu()
@@ -354,6 +566,105 @@ func (lk *LogKeyboard) HandleKeyEvents(event keyboard.KeyEvent, ctx context.Cont
 	}
 }
 
+// serviceAction runs the service action bound to key on the selected service
+func (lk *LogKeyboard) serviceAction(ctx context.Context, key rune) {
+	service, ok := lk.Watch.selectedService()
+	if lk.actions == nil || !ok {
+		return
+	}
+	switch key {
+	case 'r':
+		lk.runServiceAction(ctx, "Restart", service, lk.actions.Restart)
+	case 's':
+		lk.runServiceAction(ctx, "Start", service, lk.actions.Start)
+	case 'x':
+		lk.runServiceAction(ctx, "Stop", service, lk.actions.Stop)
+	case 'e':
+		lk.openShell(ctx, service)
+	case 'l':
+		lk.toggleLogs(service)
+		lk.printNavigationMenu()
+	case 'c':
+		eg.Go(tracing.EventWrapFuncForErrGroup(ctx, "menu/ps", tracing.SpanOptions{},
+			func(ctx context.Context) error {
+				containers, err := lk.actions.Ps(ctx)
+				if err != nil {
+					lk.keyboardError("Containers", err)
+					return err
+				}
+				lk.showOverlay(containersOverlay(containers))
+				return nil
+			}))
+	}
+}
+
+func (lk *LogKeyboard) runServiceAction(ctx context.Context, name string, service string, fn func(context.Context, string) error) {
+	eg.Go(tracing.EventWrapFuncForErrGroup(ctx, "menu/"+strings.ToLower(name), tracing.SpanOptions{},
+		func(ctx context.Context) error {
+			err := fn(ctx, service)
+			if err != nil {
+				lk.keyboardError(name, err)
+			}
+			return err
+		}))
+}
+
+// openShell releases the keyboard and the terminal to a shell running in the service container,
+// then takes them back once the shell exits
+func (lk *LogKeyboard) openShell(ctx context.Context, service string) {
+	_ = keyboard.Close()
+	lk.clearNavigationMenu()
+	ShowCursor()
+	lk.shell.Store(true)
+	lk.logLevel = NONE
+
+	err := lk.actions.Shell(ctx, service)
+
+	lk.shell.Store(false)
+	lk.logLevel = INFO
+	kEvents, kErr := keyboard.GetKeys(100)
+	if kErr != nil {
+		// keep the previous, closed, channel: the menu won't react to keys anymore
+		logrus.Warn("could not restore menu after shell exited")
+		return
+	}
+	lk.KeyEvents = kEvents
+	if err != nil {
+		lk.keyboardError("Shell", err)
+		return
+	}
+	lk.printNavigationMenu()
+}
+
+func (lk *LogKeyboard) showOverlay(text string) {
+	lk.overlay.text = text
+	lk.overlay.timeStart = time.Now()
+
+	lk.printNavigationMenu()
+	timer1 := time.NewTimer((DISPLAY_ERROR_TIME + 1) * time.Second)
+	go func() {
+		<-timer1.C
+		lk.printNavigationMenu()
+	}()
+}
+
+func containersOverlay(containers []api.ContainerSummary) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tSERVICE\tSTATUS\tPORTS")
+	for _, c := range containers {
+		var ports []string
+		for _, p := range c.Publishers {
+			if p.PublishedPort > 0 {
+				ports = append(ports, fmt.Sprintf("%d->%d/%s", p.PublishedPort, p.TargetPort, p.Protocol))
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Name, c.Service, c.Status, strings.Join(ports, ", "))
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
 func allocateSpace(lines int) {
 	for i := 0; i < lines; i++ {
 		ClearLine()
@@ -0,0 +1,72 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"gotest.tools/v3/assert"
+)
+
+func TestServiceSelection(t *testing.T) {
+	kw := KeyboardWatch{Services: []string{"api", "db", "worker"}}
+	selected, ok := kw.selectedService()
+	assert.Assert(t, ok)
+	assert.Equal(t, selected, "api")
+
+	kw.selectNext()
+	kw.selectNext()
+	selected, _ = kw.selectedService()
+	assert.Equal(t, selected, "worker")
+
+	kw.selectNext()
+	selected, _ = kw.selectedService()
+	assert.Equal(t, selected, "api")
+}
+
+func TestToggleLogs(t *testing.T) {
+	lk := LogKeyboard{}
+	assert.Assert(t, !lk.IsLogMuted("api"))
+	lk.toggleLogs("api")
+	assert.Assert(t, lk.IsLogMuted("api"))
+	assert.Assert(t, !lk.IsLogMuted("db"))
+	lk.toggleLogs("api")
+	assert.Assert(t, !lk.IsLogMuted("api"))
+
+	lk.shell.Store(true)
+	assert.Assert(t, lk.IsLogMuted("db"))
+}
+
+func TestContainersOverlay(t *testing.T) {
+	overlay := containersOverlay([]api.ContainerSummary{
+		{
+			Name:    "project-api-1",
+			Service: "api",
+			Status:  "Up 2 minutes",
+			Publishers: api.PortPublishers{
+				{TargetPort: 80, PublishedPort: 8080, Protocol: "tcp"},
+				{TargetPort: 443, Protocol: "tcp"},
+			},
+		},
+	})
+	lines := strings.Split(strings.TrimSpace(overlay), "\n")
+	assert.Equal(t, len(lines), 2)
+	assert.Assert(t, strings.HasPrefix(lines[0], "NAME"))
+	assert.Equal(t, strings.Join(strings.Fields(lines[1]), " "), "project-api-1 api Up 2 minutes 8080->80/tcp")
+}
@@ -18,6 +18,7 @@ package ocipush
 
 import (
 	"context"
+	"crypto"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -54,6 +55,19 @@ const (
 	// 	> an artifactType field, and tooling to work with artifacts should
 	//	> fallback to the config.mediaType value.
 	ComposeEmptyConfigMediaType = "application/vnd.docker.compose.config.empty.v1+json"
+	// ComposeIncludeMediaType is the media type for a local Compose file
+	// included by the project, restored at its path rather than merged with
+	// the project Compose files.
+	ComposeIncludeMediaType = "application/vnd.docker.compose.include+yaml"
+	// ComposeEnvFileMediaType is the media type for a service `env_file`.
+	ComposeEnvFileMediaType = "application/vnd.docker.compose.envfile"
+	// ComposeConfigFileMediaType is the media type for the file of a
+	// file-based `config`.
+	ComposeConfigFileMediaType = "application/vnd.docker.compose.configfile"
+
+	// ComposePathAnnotation is the annotation set on project files layers with
+	// their path relative to the project directory.
+	ComposePathAnnotation = "com.docker.compose.path"
 )
 
 // clientAuthStatusCodes are client (4xx) errors that are authentication
@@ -81,12 +95,36 @@ func DescriptorForComposeFile(path string, content []byte) v1.Descriptor {
 	}
 }
 
+// DescriptorForProjectFile returns the descriptor of a project file layer, path being relative to the project directory
+func DescriptorForProjectFile(mediaType string, path string, content []byte) v1.Descriptor {
+	return v1.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+		Annotations: map[string]string{
+			"com.docker.compose.version": api.ComposeVersion,
+			"com.docker.compose.file":    filepath.Base(path),
+			ComposePathAnnotation:        filepath.ToSlash(path),
+		},
+	}
+}
+
+// IsProjectFile returns true when the layer is a project file to be restored at its path
+func IsProjectFile(layer v1.Descriptor) bool {
+	switch layer.MediaType {
+	case ComposeIncludeMediaType, ComposeEnvFileMediaType, ComposeConfigFileMediaType:
+		return true
+	}
+	return false
+}
+
 func PushManifest(
 	ctx context.Context,
 	resolver *imagetools.Resolver,
 	named reference.Named,
 	layers []Pushable,
 	ociVersion api.OCIVersion,
+	signer crypto.Signer,
 ) error {
 	// prepare to push the manifest by pushing the layers
 	layerDescriptors := make([]v1.Descriptor, len(layers))
@@ -97,19 +135,28 @@ func PushManifest(
 		}
 	}
 
+	var annotations map[string]string
+	if signer != nil {
+		signature, err := SignLayers(layerDescriptors, signer)
+		if err != nil {
+			return err
+		}
+		annotations = signature
+	}
+
 	if ociVersion != "" {
 		// if a version was explicitly specified, use it
-		return createAndPushManifest(ctx, resolver, named, layerDescriptors, ociVersion)
+		return createAndPushManifest(ctx, resolver, named, layerDescriptors, ociVersion, annotations)
 	}
 
 	// try to push in the OCI 1.1 format but fallback to OCI 1.0 on 4xx errors
 	// (other than auth) since it's most likely the result of the registry not
 	// having support
-	err := createAndPushManifest(ctx, resolver, named, layerDescriptors, api.OCIVersion1_1)
+	err := createAndPushManifest(ctx, resolver, named, layerDescriptors, api.OCIVersion1_1, annotations)
 	var pushErr pusherrors.ErrUnexpectedStatus
 	if errors.As(err, &pushErr) && isNonAuthClientError(pushErr.StatusCode) {
 		// TODO(milas): show a warning here (won't work with logrus)
-		return createAndPushManifest(ctx, resolver, named, layerDescriptors, api.OCIVersion1_0)
+		return createAndPushManifest(ctx, resolver, named, layerDescriptors, api.OCIVersion1_0, annotations)
 	}
 	return err
 }
@@ -120,8 +167,9 @@ func createAndPushManifest(
 	named reference.Named,
 	layers []v1.Descriptor,
 	ociVersion api.OCIVersion,
+	annotations map[string]string,
 ) error {
-	toPush, err := generateManifest(layers, ociVersion)
+	toPush, err := generateManifest(layers, ociVersion, annotations)
 	if err != nil {
 		return err
 	}
@@ -149,7 +197,7 @@ func isNonAuthClientError(statusCode int) bool {
 	return true
 }
 
-func generateManifest(layers []v1.Descriptor, ociCompat api.OCIVersion) ([]Pushable, error) {
+func generateManifest(layers []v1.Descriptor, ociCompat api.OCIVersion, annotations map[string]string) ([]Pushable, error) {
 	var toPush []Pushable
 	var config v1.Descriptor
 	var artifactType string
@@ -185,15 +233,19 @@ func generateManifest(layers []v1.Descriptor, ociCompat api.OCIVersion) ([]Pusha
 		return nil, fmt.Errorf("unsupported OCI version: %s", ociCompat)
 	}
 
+	manifestAnnotations := map[string]string{
+		"org.opencontainers.image.created": time.Now().Format(time.RFC3339),
+	}
+	for k, v := range annotations {
+		manifestAnnotations[k] = v
+	}
 	manifest, err := json.Marshal(v1.Manifest{
 		Versioned:    specs.Versioned{SchemaVersion: 2},
 		MediaType:    v1.MediaTypeImageManifest,
 		ArtifactType: artifactType,
 		Config:       config,
 		Layers:       layers,
-		Annotations: map[string]string{
-			"org.opencontainers.image.created": time.Now().Format(time.RFC3339),
-		},
+		Annotations:  manifestAnnotations,
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,178 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ocipush
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// ComposeSignatureAnnotation is the manifest annotation holding the
+	// base64-encoded signature of the manifest layers.
+	ComposeSignatureAnnotation = "com.docker.compose.signature"
+	// ComposeSignatureKeyAnnotation is the manifest annotation holding the
+	// fingerprint of the public key to verify the signature with.
+	ComposeSignatureKeyAnnotation = "com.docker.compose.signature.key"
+)
+
+// ErrNotSigned is returned when verifying a manifest without a signature
+var ErrNotSigned = errors.New("artifact is not signed")
+
+// LoadSigningKey reads a PEM encoded private key (PKCS#8, EC or PKCS#1)
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing key type %T", key)
+	}
+	return signer, nil
+}
+
+// LoadPublicKey reads a PEM encoded PKIX public key
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded key", path)
+	}
+	return block, nil
+}
+
+// KeyFingerprint returns the digest of the DER encoding of a public key
+func KeyFingerprint(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return digest.FromBytes(der).String(), nil
+}
+
+// SignLayers signs the layers descriptors, and returns the annotations to set on the manifest to carry the signature
+func SignLayers(layers []v1.Descriptor, key crypto.Signer) (map[string]string, error) {
+	payload, err := json.Marshal(layers)
+	if err != nil {
+		return nil, err
+	}
+	var signature []byte
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		signature, err = key.Sign(rand.Reader, payload, crypto.Hash(0))
+	} else {
+		sum := sha256.Sum256(payload)
+		signature, err = key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := KeyFingerprint(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		ComposeSignatureAnnotation:    base64.StdEncoding.EncodeToString(signature),
+		ComposeSignatureKeyAnnotation: fingerprint,
+	}, nil
+}
+
+// VerifyManifest checks the manifest layers have been signed by one of the trusted keys
+func VerifyManifest(manifest v1.Manifest, trusted []crypto.PublicKey) error {
+	encoded, ok := manifest.Annotations[ComposeSignatureAnnotation]
+	if !ok {
+		return ErrNotSigned
+	}
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	fingerprint := manifest.Annotations[ComposeSignatureKeyAnnotation]
+	for _, key := range trusted {
+		f, err := KeyFingerprint(key)
+		if err != nil {
+			return err
+		}
+		if f != fingerprint {
+			continue
+		}
+		payload, err := json.Marshal(manifest.Layers)
+		if err != nil {
+			return err
+		}
+		if !verifySignature(key, payload, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("artifact is signed by an untrusted key %s", fingerprint)
+}
+
+func verifySignature(key crypto.PublicKey, payload []byte, signature []byte) bool {
+	sum := sha256.Sum256(payload)
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, payload, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, sum[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], signature) == nil
+	default:
+		return false
+	}
+}
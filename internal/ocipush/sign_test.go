@@ -0,0 +1,94 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package ocipush
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+func writeKeys(t *testing.T, key crypto.Signer) (string, string) {
+	dir := t.TempDir()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NilError(t, err)
+	private := filepath.Join(dir, "key.pem")
+	assert.NilError(t, os.WriteFile(private, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	der, err = x509.MarshalPKIXPublicKey(key.Public())
+	assert.NilError(t, err)
+	public := filepath.Join(dir, "key.pub")
+	assert.NilError(t, os.WriteFile(public, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return private, public
+}
+
+func TestSignAndVerify(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	for name, key := range map[string]crypto.Signer{"ed25519": edKey, "ecdsa": ecKey} {
+		t.Run(name, func(t *testing.T) {
+			private, public := writeKeys(t, key)
+			signer, err := LoadSigningKey(private)
+			assert.NilError(t, err)
+			trusted, err := LoadPublicKey(public)
+			assert.NilError(t, err)
+
+			layers := []v1.Descriptor{
+				DescriptorForComposeFile("compose.yaml", []byte("services: {}")),
+				DescriptorForProjectFile(ComposeEnvFileMediaType, "app.env", []byte("LOG_LEVEL=debug")),
+			}
+			annotations, err := SignLayers(layers, signer)
+			assert.NilError(t, err)
+
+			manifest := v1.Manifest{Layers: layers, Annotations: annotations}
+			assert.NilError(t, VerifyManifest(manifest, []crypto.PublicKey{trusted}))
+
+			tampered := manifest
+			tampered.Layers = []v1.Descriptor{layers[0], DescriptorForProjectFile(ComposeEnvFileMediaType, "app.env", []byte("LOG_LEVEL=trace"))}
+			assert.Error(t, VerifyManifest(tampered, []crypto.PublicKey{trusted}), "invalid signature")
+		})
+	}
+}
+
+func TestVerifyManifestPolicy(t *testing.T) {
+	signerPub, signer, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NilError(t, err)
+
+	layers := []v1.Descriptor{DescriptorForComposeFile("compose.yaml", []byte("services: {}"))}
+	err = VerifyManifest(v1.Manifest{Layers: layers}, []crypto.PublicKey{signerPub})
+	assert.ErrorIs(t, err, ErrNotSigned)
+
+	annotations, err := SignLayers(layers, signer)
+	assert.NilError(t, err)
+	err = VerifyManifest(v1.Manifest{Layers: layers, Annotations: annotations}, []crypto.PublicKey{otherPub})
+	assert.ErrorContains(t, err, "artifact is signed by an untrusted key sha256:")
+}
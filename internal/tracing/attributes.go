@@ -156,6 +156,24 @@ func ContainerOptions(container moby.Container) SpanOptions {
 	}
 }
 
+// DependencyOptions returns common attributes from a service dependency.
+//
+// For convenience, it's returned as a SpanOptions object to allow it to be
+// passed directly to the wrapping helper methods in this package such as
+// SpanWrapFunc.
+func DependencyOptions(dependant, dependency string, config types.ServiceDependency) SpanOptions {
+	attrs := []attribute.KeyValue{
+		attribute.String("dependency.dependant", dependant),
+		attribute.String("dependency.name", dependency),
+		attribute.String("dependency.condition", config.Condition),
+		attribute.Bool("dependency.required", config.Required),
+	}
+
+	return []trace.SpanStartEventOption{
+		trace.WithAttributes(attrs...),
+	}
+}
+
 func keys[T any](m map[string]T) []string {
 	out := make([]string, 0, len(m))
 	for k := range m {
@@ -0,0 +1,366 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	// TraceFileEnv is the environment variable setting a file to write the spans to
+	TraceFileEnv = "COMPOSE_TRACE_FILE"
+	// TraceFormatEnv is the environment variable selecting the format of TraceFileEnv
+	TraceFormatEnv = "COMPOSE_TRACE_FORMAT"
+
+	// TraceFormatOTLP writes the spans as an OTLP-JSON export request, as read by the OpenTelemetry collector and Jaeger
+	TraceFormatOTLP = "otlp"
+	// TraceFormatChrome writes the spans as Chrome trace events, as read by Perfetto and chrome://tracing
+	TraceFormatChrome = "chrome"
+)
+
+// FileExporter writes the spans to a file, so a trace can be loaded in a viewer without running a collector. The
+// file is rewritten with all the spans on each export, so it is complete even if the shutdown flush gets cut short
+type FileExporter struct {
+	path   string
+	format string
+	mtx    sync.Mutex
+	spans  []sdktrace.ReadOnlySpan
+}
+
+// NewFileExporter creates an exporter writing the spans to path in the given format
+func NewFileExporter(path, format string) (*FileExporter, error) {
+	switch format {
+	case "":
+		format = TraceFormatOTLP
+	case TraceFormatOTLP, TraceFormatChrome:
+	default:
+		return nil, fmt.Errorf("unsupported %s %q, must be one of %s or %s", TraceFormatEnv, format, TraceFormatOTLP, TraceFormatChrome)
+	}
+	return &FileExporter{path: path, format: format}, nil
+}
+
+func (f *FileExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.spans = append(f.spans, spans...)
+
+	file, err := os.Create(f.path)
+	if err != nil {
+		return err
+	}
+	if f.format == TraceFormatChrome {
+		err = writeChromeTrace(file, f.spans)
+	} else {
+		err = writeOTLPJSON(file, f.spans)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (f *FileExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// OTLP-JSON encoding, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type otlpTraceRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string         `json:"stringValue,omitempty"`
+	BoolValue   *bool           `json:"boolValue,omitempty"`
+	IntValue    *string         `json:"intValue,omitempty"`
+	DoubleValue *float64        `json:"doubleValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpValue `json:"values"`
+}
+
+// writeOTLPJSON writes the spans as a single OTLP export request, grouped by resource and instrumentation scope
+func writeOTLPJSON(out io.Writer, spans []sdktrace.ReadOnlySpan) error {
+	var request otlpTraceRequest
+	resources := map[attribute.Distinct]int{}
+	scopes := map[string]int{}
+	for _, span := range spans {
+		var key attribute.Distinct
+		if res := span.Resource(); res != nil {
+			key = res.Equivalent()
+		}
+		r, ok := resources[key]
+		if !ok {
+			r = len(request.ResourceSpans)
+			resources[key] = r
+			var attrs []attribute.KeyValue
+			if res := span.Resource(); res != nil {
+				attrs = res.Attributes()
+			}
+			request.ResourceSpans = append(request.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{Attributes: otlpAttributes(attrs)},
+			})
+		}
+		resourceSpans := &request.ResourceSpans[r]
+
+		scope := span.InstrumentationScope()
+		scopeKey := fmt.Sprintf("%d/%s/%s", r, scope.Name, scope.Version)
+		s, ok := scopes[scopeKey]
+		if !ok {
+			s = len(resourceSpans.ScopeSpans)
+			scopes[scopeKey] = s
+			resourceSpans.ScopeSpans = append(resourceSpans.ScopeSpans, otlpScopeSpans{
+				Scope: otlpScope{Name: scope.Name, Version: scope.Version},
+			})
+		}
+		scopeSpans := &resourceSpans.ScopeSpans[s]
+		scopeSpans.Spans = append(scopeSpans.Spans, otlpSpanFrom(span))
+	}
+	encoder := json.NewEncoder(out)
+	return encoder.Encode(request)
+}
+
+func otlpSpanFrom(span sdktrace.ReadOnlySpan) otlpSpan {
+	s := otlpSpan{
+		TraceID:           span.SpanContext().TraceID().String(),
+		SpanID:            span.SpanContext().SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime().UnixNano(), 10),
+		Attributes:        otlpAttributes(span.Attributes()),
+		Status:            otlpStatus{Message: span.Status().Description},
+	}
+	if span.Parent().HasSpanID() {
+		s.ParentSpanID = span.Parent().SpanID().String()
+	}
+	// OTLP status codes don't have the same values as the OpenTelemetry API ones
+	switch span.Status().Code {
+	case codes.Ok:
+		s.Status.Code = 1
+	case codes.Error:
+		s.Status.Code = 2
+	}
+	for _, event := range span.Events() {
+		s.Events = append(s.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(event.Time.UnixNano(), 10),
+			Name:         event.Name,
+			Attributes:   otlpAttributes(event.Attributes),
+		})
+	}
+	return s
+}
+
+func otlpAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	var values []otlpKeyValue
+	for _, attr := range attrs {
+		values = append(values, otlpKeyValue{Key: string(attr.Key), Value: otlpValueFrom(attr.Value)})
+	}
+	return values
+}
+
+func otlpValueFrom(v attribute.Value) otlpValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		var values []otlpValue
+		for _, b := range v.AsBoolSlice() {
+			values = append(values, otlpValueFrom(attribute.BoolValue(b)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		var values []otlpValue
+		for _, i := range v.AsInt64Slice() {
+			values = append(values, otlpValueFrom(attribute.Int64Value(i)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		var values []otlpValue
+		for _, f := range v.AsFloat64Slice() {
+			values = append(values, otlpValueFrom(attribute.Float64Value(f)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		var values []otlpValue
+		for _, s := range v.AsStringSlice() {
+			values = append(values, otlpValueFrom(attribute.StringValue(s)))
+		}
+		return otlpValue{ArrayValue: &otlpArrayValue{Values: values}}
+	default:
+		s := v.Emit()
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// Chrome trace event format, see https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+
+type chromeTrace struct {
+	TraceEvents     []chromeEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+type chromeEvent struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat"`
+	Phase     string         `json:"ph"`
+	Timestamp int64          `json:"ts"`
+	Duration  int64          `json:"dur,omitempty"`
+	PID       int            `json:"pid"`
+	TID       int            `json:"tid"`
+	Scope     string         `json:"s,omitempty"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// writeChromeTrace writes the spans as complete events. Viewers expect the events of a thread to be nested, so
+// spans running concurrently are spread over distinct threads
+func writeChromeTrace(out io.Writer, spans []sdktrace.ReadOnlySpan) error {
+	sorted := make([]sdktrace.ReadOnlySpan, len(spans))
+	copy(sorted, spans)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].StartTime().Equal(sorted[j].StartTime()) {
+			return sorted[i].EndTime().After(sorted[j].EndTime())
+		}
+		return sorted[i].StartTime().Before(sorted[j].StartTime())
+	})
+
+	trace := chromeTrace{TraceEvents: []chromeEvent{}, DisplayTimeUnit: "ms"}
+	var threads [][]sdktrace.ReadOnlySpan
+	for _, span := range sorted {
+		tid := -1
+		for i, stack := range threads {
+			// drop the spans of the thread which completed before this one started
+			for len(stack) > 0 && !stack[len(stack)-1].EndTime().After(span.StartTime()) {
+				stack = stack[:len(stack)-1]
+			}
+			threads[i] = stack
+			if len(stack) == 0 || !stack[len(stack)-1].EndTime().Before(span.EndTime()) {
+				tid = i
+				break
+			}
+		}
+		if tid < 0 {
+			tid = len(threads)
+			threads = append(threads, nil)
+		}
+		threads[tid] = append(threads[tid], span)
+
+		args := map[string]any{}
+		for _, attr := range span.Attributes() {
+			args[string(attr.Key)] = attr.Value.AsInterface()
+		}
+		if span.Status().Code == codes.Error {
+			args["error"] = span.Status().Description
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeEvent{
+			Name:      span.Name(),
+			Category:  "compose",
+			Phase:     "X",
+			Timestamp: span.StartTime().UnixMicro(),
+			Duration:  span.EndTime().Sub(span.StartTime()).Microseconds(),
+			PID:       1,
+			TID:       tid + 1,
+			Args:      args,
+		})
+		for _, event := range span.Events() {
+			eventArgs := map[string]any{}
+			for _, attr := range event.Attributes {
+				eventArgs[string(attr.Key)] = attr.Value.AsInterface()
+			}
+			trace.TraceEvents = append(trace.TraceEvents, chromeEvent{
+				Name:      event.Name,
+				Category:  "compose",
+				Phase:     "i",
+				Timestamp: event.Time.UnixMicro(),
+				PID:       1,
+				TID:       tid + 1,
+				Scope:     "t",
+				Args:      eventArgs,
+			})
+		}
+	}
+	encoder := json.NewEncoder(out)
+	return encoder.Encode(trace)
+}
@@ -0,0 +1,150 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/docker/compose/v2/internal/tracing"
+)
+
+// traceToFile records a span for a project with two services started concurrently, one of them failing
+func traceToFile(t *testing.T, format string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trace.json")
+	exporter, err := tracing.NewFileExporter(path, format)
+	require.NoError(t, err)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := provider.Tracer("compose")
+
+	start := time.Now()
+	ctx, up := tracer.Start(context.Background(), "project/up", trace.WithTimestamp(start))
+	_, db := tracer.Start(ctx, "service/apply", trace.WithTimestamp(start),
+		trace.WithAttributes(attribute.String("service.name", "db"), attribute.Int("service.replicas", 2)))
+	_, web := tracer.Start(ctx, "service/apply", trace.WithTimestamp(start.Add(time.Second)),
+		trace.WithAttributes(attribute.String("service.name", "web")))
+	web.AddEvent("container/start", trace.WithTimestamp(start.Add(2*time.Second)))
+	db.End(trace.WithTimestamp(start.Add(3 * time.Second)))
+	web.RecordError(errors.New("port is already allocated"))
+	web.SetStatus(codes.Error, "port is already allocated")
+	web.End(trace.WithTimestamp(start.Add(4 * time.Second)))
+	up.End(trace.WithTimestamp(start.Add(5 * time.Second)))
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	return path
+}
+
+func TestFileExporterOTLP(t *testing.T) {
+	path := traceToFile(t, "")
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Attributes   []struct {
+						Key   string         `json:"key"`
+						Value map[string]any `json:"value"`
+					} `json:"attributes"`
+					Status struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	require.NoError(t, json.Unmarshal(content, &request))
+	require.Len(t, request.ResourceSpans, 1)
+	require.Len(t, request.ResourceSpans[0].ScopeSpans, 1)
+	scope := request.ResourceSpans[0].ScopeSpans[0]
+	require.Equal(t, "compose", scope.Scope.Name)
+	require.Len(t, scope.Spans, 3)
+
+	db, web, up := scope.Spans[0], scope.Spans[1], scope.Spans[2]
+	require.Equal(t, "project/up", up.Name)
+	require.Empty(t, up.ParentSpanID)
+	require.Len(t, up.TraceID, 32)
+	require.Equal(t, up.SpanID, db.ParentSpanID)
+	require.Equal(t, up.TraceID, db.TraceID)
+
+	require.Equal(t, "service.name", db.Attributes[0].Key)
+	require.Equal(t, map[string]any{"stringValue": "db"}, db.Attributes[0].Value)
+	require.Equal(t, map[string]any{"intValue": "2"}, db.Attributes[1].Value)
+	require.Equal(t, 2, web.Status.Code)
+}
+
+func TestFileExporterChrome(t *testing.T) {
+	path := traceToFile(t, tracing.TraceFormatChrome)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var chrome struct {
+		TraceEvents []struct {
+			Name      string         `json:"name"`
+			Phase     string         `json:"ph"`
+			Timestamp int64          `json:"ts"`
+			Duration  int64          `json:"dur"`
+			TID       int            `json:"tid"`
+			Args      map[string]any `json:"args"`
+		} `json:"traceEvents"`
+	}
+	require.NoError(t, json.Unmarshal(content, &chrome))
+	require.Len(t, chrome.TraceEvents, 5)
+
+	up, db, web, start := chrome.TraceEvents[0], chrome.TraceEvents[1], chrome.TraceEvents[2], chrome.TraceEvents[3]
+	require.Equal(t, "project/up", up.Name)
+	require.Equal(t, "X", up.Phase)
+	require.Equal(t, int64(5_000_000), up.Duration)
+
+	// db is nested in up, web overlaps with db so it must be on another thread
+	require.Equal(t, "db", db.Args["service.name"])
+	require.Equal(t, up.TID, db.TID)
+	require.Equal(t, "web", web.Args["service.name"])
+	require.NotEqual(t, db.TID, web.TID)
+	require.Equal(t, "port is already allocated", web.Args["error"])
+	require.Equal(t, int64(1_000_000), web.Timestamp-up.Timestamp)
+
+	require.Equal(t, "container/start", start.Name)
+	require.Equal(t, "i", start.Phase)
+	require.Equal(t, web.TID, start.TID)
+	require.Equal(t, "exception", chrome.TraceEvents[4].Name)
+}
+
+func TestFileExporterFormat(t *testing.T) {
+	_, err := tracing.NewFileExporter("trace.json", "zipkin")
+	require.EqualError(t, err, `unsupported COMPOSE_TRACE_FORMAT "zipkin", must be one of otlp or chrome`)
+}
@@ -89,6 +89,14 @@ func InitProvider(dockerCli command.Cli) (ShutdownFunc, error) {
 			exporters = append(exporters, dcExporter)
 		}
 	}
+	if path := os.Getenv(TraceFileEnv); path != "" {
+		if fileExporter, err := NewFileExporter(path, os.Getenv(TraceFormatEnv)); err != nil {
+			errs = append(errs, err)
+		} else {
+			exporters = append(exporters, fileExporter)
+		}
+	}
+
 	if len(errs) != 0 {
 		return nil, errors.Join(errs...)
 	}
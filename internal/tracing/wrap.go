@@ -96,3 +96,10 @@ func AddAttributeToSpan(ctx context.Context, attr ...attribute.KeyValue) {
 	span := trace.SpanFromContext(ctx)
 	span.SetAttributes(attr...)
 }
+
+// AddEventToSpan records an event on the span of the context, like an iteration of a polling loop which doesn't
+// deserve its own span
+func AddEventToSpan(ctx context.Context, name string, attr ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	span.AddEvent(name, trace.WithAttributes(attr...))
+}
@@ -58,6 +58,8 @@ type Service interface {
 	RunOneOffContainer(ctx context.Context, project *types.Project, opts RunOptions) (int, error)
 	// Remove executes the equivalent to a `compose rm`
 	Remove(ctx context.Context, projectName string, options RemoveOptions) error
+	// Prune executes the equivalent to a `compose prune`
+	Prune(ctx context.Context, projectName string, options PruneOptions) error
 	// Exec executes a command in a running service container
 	Exec(ctx context.Context, projectName string, options RunOptions) (int, error)
 	// Attach STDIN,STDOUT,STDERR to a running service container
@@ -94,6 +96,12 @@ type Service interface {
 	Export(ctx context.Context, projectName string, options ExportOptions) error
 	// Generate generates a Compose Project from existing containers
 	Generate(ctx context.Context, options GenerateOptions) (*types.Project, error)
+	// Save executes the equivalent of a `compose save`
+	Save(ctx context.Context, project *types.Project, options SaveOptions) error
+	// Load executes the equivalent of a `compose load`
+	Load(ctx context.Context, options LoadOptions) error
+	// RefreshSecrets executes the equivalent of a `compose secrets refresh`
+	RefreshSecrets(ctx context.Context, project *types.Project, options RefreshSecretsOptions) error
 }
 
 type ScaleOptions struct {
@@ -118,6 +126,13 @@ type VizOptions struct {
 	Indentation string
 }
 
+// UntrustedSourceExtension is set on projects loaded from a remote source the user doesn't trust, with the source
+// reference, so the commands they declare to run on the host are refused
+const UntrustedSourceExtension = "x-untrusted-source"
+
+// SecretProviderExtension is the secret extension selecting the provider the secret value is read from
+const SecretProviderExtension = "x-provider"
+
 // WatchLogger is a reserved name to log watch events
 const WatchLogger = "#watch"
 
@@ -126,6 +141,28 @@ type WatchOptions struct {
 	Build *BuildOptions
 	LogTo LogConsumer
 	Prune bool
+	// Commands receives requests to drive individual watched services while watch is running
+	Commands <-chan WatchCommand
+}
+
+// WatchCommandAction is an action to apply on a watched service
+type WatchCommandAction string
+
+const (
+	// WatchPause holds back the file changes of a service until it is resumed
+	WatchPause WatchCommandAction = "pause"
+	// WatchResume applies the file changes held back while a service was paused, and handles new ones again
+	WatchResume WatchCommandAction = "resume"
+	// WatchSync copies all files watched with a sync action into the service containers
+	WatchSync WatchCommandAction = "sync"
+	// WatchRebuild rebuilds the service image and recreates its containers
+	WatchRebuild WatchCommandAction = "rebuild"
+)
+
+// WatchCommand requests an action on a single watched service
+type WatchCommand struct {
+	Service string
+	Action  WatchCommandAction
 }
 
 // BuildOptions group options of the Build API
@@ -152,6 +189,52 @@ type BuildOptions struct {
 	Memory int64
 	// Builder name passed in the command line
 	Builder string
+	// Summary prints a table summarizing time, cache usage and size of each service build
+	Summary bool
+	// Report is the path of a file to write the build summary to, as JSON
+	Report string
+	// Platforms to build each selected service for, as a matrix combined with Targets
+	Platforms []string
+	// Targets to build each selected service for, as a matrix combined with Platforms
+	Targets []string
+}
+
+// BuildReport summarizes the build of a service image
+type BuildReport struct {
+	Service string `json:"service"`
+	Image   string `json:"image"`
+	ImageID string `json:"imageId,omitempty"`
+	// Duration is the total build time, in nanoseconds
+	Duration time.Duration      `json:"duration"`
+	Stages   []BuildStageReport `json:"stages,omitempty"`
+	// Steps is the number of build steps run, cached or not
+	Steps int `json:"steps"`
+	// CachedSteps is the number of build steps resolved from cache
+	CachedSteps int `json:"cachedSteps"`
+	// Size of the built image in bytes, 0 if the image isn't available in the engine image store
+	Size int64 `json:"size"`
+	// PreviousSize is the size of the image the build replaced, 0 if there was none
+	PreviousSize int64 `json:"previousSize"`
+	// Failed is set when the build of the service didn't complete, Error then tells why
+	Failed bool   `json:"failed,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CacheRatio returns the ratio of build steps resolved from cache
+func (r BuildReport) CacheRatio() float64 {
+	if r.Steps == 0 {
+		return 0
+	}
+	return float64(r.CachedSteps) / float64(r.Steps)
+}
+
+// BuildStageReport summarizes a stage of a multi-stage build
+type BuildStageReport struct {
+	Name string `json:"name"`
+	// Duration of the stage, in nanoseconds
+	Duration    time.Duration `json:"duration"`
+	Steps       int           `json:"steps"`
+	CachedSteps int           `json:"cachedSteps"`
 }
 
 // Apply mutates project according to build options
@@ -204,6 +287,10 @@ type CreateOptions struct {
 	Timeout *time.Duration
 	// QuietPull makes the pulling process quiet
 	QuietPull bool
+	// PullRetry defines how failing pulls are retried
+	PullRetry PullRetryPolicy
+	// PullConcurrency is the maximum number of images pulled at the same time, defaults to the global parallelism limit
+	PullConcurrency int
 }
 
 // StartOptions group options of the Start API
@@ -261,6 +348,9 @@ type StopOptions struct {
 type UpOptions struct {
 	Create CreateOptions
 	Start  StartOptions
+	// Timings prints the time spent by each service pulling, building, creating, waiting for its dependencies,
+	// starting and becoming healthy once the services are started
+	Timings bool
 }
 
 // DownOptions group options of the Down API
@@ -301,6 +391,20 @@ type PullOptions struct {
 	Quiet           bool
 	IgnoreFailures  bool
 	IgnoreBuildable bool
+	// Retry defines how failing pulls are retried
+	Retry PullRetryPolicy
+	// Concurrency is the maximum number of images pulled at the same time, defaults to the global parallelism limit
+	Concurrency int
+}
+
+// PullRetryPolicy defines how image pulls failing for a transient reason are retried
+type PullRetryPolicy struct {
+	// Attempts is the maximum number of attempts to pull an image from a registry, retries are disabled when lower than 2
+	Attempts int
+	// Backoff is the delay before the first retry, doubled for each subsequent retry and randomized with jitter
+	Backoff time.Duration
+	// Mirrors are registries to pull the image from, in order, when it can't be pulled from its own registry
+	Mirrors []string
 }
 
 // ImagesOptions group options of the Images API
@@ -336,6 +440,16 @@ type RemoveOptions struct {
 	Services []string
 }
 
+// PruneOptions group options of the Prune API
+type PruneOptions struct {
+	// OneOff removes the stopped one-off containers created by `compose run`, and their anonymous volumes
+	OneOff bool
+	// OlderThan only removes the containers stopped for longer than this duration
+	OlderThan time.Duration
+	// Force don't ask to confirm removal
+	Force bool
+}
+
 // RunOptions group options of the Run API
 type RunOptions struct {
 	Build *BuildOptions
@@ -360,6 +474,10 @@ type RunOptions struct {
 	NoDeps            bool
 	// QuietPull makes the pulling process quiet
 	QuietPull bool
+	// PullRetry defines how failing pulls are retried
+	PullRetry PullRetryPolicy
+	// GC removes the stopped one-off containers of the project before running
+	GC bool
 	// used by exec
 	Index int
 }
@@ -417,6 +535,12 @@ type PublishOptions struct {
 	ResolveImageDigests bool
 
 	OCIVersion OCIVersion
+	// Includes are the local Compose files included by the project, published as extra layers
+	Includes []string
+	// AssumeYes publishes files which look like they contain secrets without asking for confirmation
+	AssumeYes bool
+	// SigningKey is the path to a PEM encoded private key to sign the published artifact with, if set
+	SigningKey string
 }
 
 func (e Event) String() string {
@@ -565,6 +689,33 @@ type ExportOptions struct {
 	Output  string
 }
 
+// SaveOptions group options of the Save API
+type SaveOptions struct {
+	// Output is the path of the bundle archive to write, STDOUT if empty
+	Output string
+	// Platforms to pull images for before saving, so the bundle includes them. Several platforms require the
+	// containerd image store, as the classic image store keeps a single platform variant per image reference.
+	Platforms []string
+	// Build options for images to be built before saving, no build if nil
+	Build *BuildOptions
+}
+
+// LoadOptions group options of the Load API
+type LoadOptions struct {
+	// Input is the path of the bundle archive to read, STDIN if empty
+	Input string
+	// Model is the path to write the Compose model included in the bundle to, if set
+	Model string
+}
+
+// RefreshSecretsOptions group options of the RefreshSecrets API
+type RefreshSecretsOptions struct {
+	// Services to refresh the secrets and configs of, all if empty
+	Services []string
+	// NoReload only updates the secrets and configs content, without reloading the services
+	NoReload bool
+}
+
 type GenerateOptions struct {
 	// ProjectName to set in the Compose file
 	ProjectName string
@@ -49,6 +49,8 @@ const (
 	SlugLabel = "com.docker.compose.slug"
 	// ImageDigestLabel stores digest of the container image used to run service
 	ImageDigestLabel = "com.docker.compose.image"
+	// ImageMirrorLabel stores the mirror reference an image pinned by digest has been pulled from
+	ImageMirrorLabel = "com.docker.compose.image.mirror"
 	// DependenciesLabel stores service dependencies
 	DependenciesLabel = "com.docker.compose.depends_on"
 	// VersionLabel stores the compose tool version used to build/run application
@@ -0,0 +1,103 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package api
+
+import "time"
+
+// ProgressEventVersion is the version of the ProgressEvent schema. It is only bumped when a field is removed or
+// its meaning changes, new fields and kinds can be added within a version
+const ProgressEventVersion = 1
+
+// ProgressEventKind is the kind of resource or operation a ProgressEvent is about
+type ProgressEventKind string
+
+const (
+	// ProgressKindImage is an image being pulled, built, pushed or removed
+	ProgressKindImage ProgressEventKind = "image"
+	// ProgressKindPullLayer is a layer of an image being pulled, its ParentID is the image event ID
+	ProgressKindPullLayer ProgressEventKind = "pull-layer"
+	// ProgressKindPushLayer is a layer of an image being pushed, its ParentID is the image event ID
+	ProgressKindPushLayer ProgressEventKind = "push-layer"
+	// ProgressKindBuildStep is a step of an image build, its ParentID is the image event ID
+	ProgressKindBuildStep ProgressEventKind = "build-step"
+	// ProgressKindContainerState is a container being created, started, stopped or removed
+	ProgressKindContainerState ProgressEventKind = "container-state"
+	// ProgressKindVolume is a volume being created or removed
+	ProgressKindVolume ProgressEventKind = "volume"
+	// ProgressKindNetwork is a network being created or removed
+	ProgressKindNetwork ProgressEventKind = "network"
+	// ProgressKindHook is a lifecycle hook running in a container or on the host
+	ProgressKindHook ProgressEventKind = "hook"
+	// ProgressKindOther is any other operation
+	ProgressKindOther ProgressEventKind = "other"
+)
+
+// ProgressState is the state of the operation a ProgressEvent is about
+type ProgressState string
+
+const (
+	// ProgressStateWorking means the operation is in progress
+	ProgressStateWorking ProgressState = "working"
+	// ProgressStateDone means the operation completed
+	ProgressStateDone ProgressState = "done"
+	// ProgressStateWarning means the operation completed with a warning, or was skipped
+	ProgressStateWarning ProgressState = "warning"
+	// ProgressStateError means the operation failed
+	ProgressStateError ProgressState = "error"
+)
+
+// ProgressEvent is a line of the `--progress json` output. Events about the same resource share the same ID, and
+// Text and Status are meant to be displayed: integrations should rely on Kind and State instead
+type ProgressEvent struct {
+	// Version is the version of the schema, ProgressEventVersion
+	Version int `json:"version"`
+	// Kind is the kind of resource or operation the event is about
+	Kind ProgressEventKind `json:"kind,omitempty"`
+	// ID identifies the resource the event is about
+	ID string `json:"id,omitempty"`
+	// ParentID is the ID of the event this one details, like the image a layer is pulled for
+	ParentID string `json:"parent_id,omitempty"`
+	// State is the state of the operation
+	State ProgressState `json:"state,omitempty"`
+	// Text describes the operation
+	Text string `json:"text,omitempty"`
+	// Status describes the state of the operation
+	Status string `json:"status,omitempty"`
+	// Timestamp is the time the event was emitted
+	Timestamp time.Time `json:"timestamp"`
+	// StartedAt is the time of the first event about the resource
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// Duration is the time the operation took in seconds, set once it completed or failed
+	Duration float64 `json:"duration,omitempty"`
+	// Current is the amount of work done, like the number of bytes downloaded
+	Current int64 `json:"current,omitempty"`
+	// Total is the amount of work to be done
+	Total int64 `json:"total,omitempty"`
+	// Percent is Current relative to Total
+	Percent int `json:"percent,omitempty"`
+	// Error details why the operation failed
+	Error *ProgressError `json:"error,omitempty"`
+	// DryRun is set when running in dry-run mode
+	DryRun bool `json:"dry-run,omitempty"`
+	// Tail is set for a message displayed once the operation completed, rather than an event about a resource
+	Tail bool `json:"tail,omitempty"`
+}
+
+// ProgressError details why an operation failed
+type ProgressError struct {
+	Message string `json:"message"`
+}
@@ -22,6 +22,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/containerd/platforms"
@@ -58,6 +61,9 @@ func (s *composeService) Build(ctx context.Context, project *types.Project, opti
 	if err != nil {
 		return err
 	}
+	if len(options.Platforms) > 0 || len(options.Targets) > 0 {
+		return s.buildMatrix(ctx, project, options)
+	}
 	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
 		_, err := s.build(ctx, project, options, nil)
 		return err
@@ -99,6 +105,17 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		return imageIDs, err
 	}
 
+	buildProject, err := withBuildDependencies(project)
+	if err != nil {
+		return nil, err
+	}
+	addReferencedBuilds(project, serviceToBeBuild, localImages)
+	if !buildkitEnabled {
+		if err := checkClassicServiceContexts(serviceToBeBuild); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize buildkit nodes
 	var (
 		b     *builder.Builder
@@ -129,7 +146,12 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		if options.Quiet {
 			options.Progress = progress.ModeQuiet
 		}
-		w, err = xprogress.NewPrinter(progressCtx, os.Stdout, progressui.DisplayMode(options.Progress),
+		mode := options.Progress
+		if mode == progress.ModeJSON {
+			// build steps are reported as progress events, with the same schema as other operations
+			mode = progress.ModeQuiet
+		}
+		w, err = xprogress.NewPrinter(progressCtx, os.Stdout, progressui.DisplayMode(mode),
 			xprogress.WithDesc(
 				fmt.Sprintf("building with %q instance using %s driver", b.Name, b.Driver),
 				fmt.Sprintf("%s:%s", b.Driver, b.Name),
@@ -142,6 +164,8 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 
 	// we use a pre-allocated []string to collect build digest by service index while running concurrent goroutines
 	builtDigests := make([]string, len(project.Services))
+	reporting := options.Summary || options.Report != ""
+	reports := make([]*api.BuildReport, len(project.Services))
 	names := project.ServiceNames()
 	getServiceIndex := func(name string) int {
 		for idx, n := range names {
@@ -151,19 +175,55 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		}
 		return -1
 	}
-	err = InDependencyOrder(ctx, project, func(ctx context.Context, name string) error {
+	err = InDependencyOrder(ctx, buildProject, func(ctx context.Context, name string) (err error) {
 		serviceToBuild, ok := serviceToBeBuild[name]
 		if !ok {
 			return nil
 		}
 		service := serviceToBuild.service
+		defer recordTiming(ctx, name, timingBuild, time.Now())
+
+		var steps *buildSteps
+		if reporting {
+			steps = newBuildSteps()
+			imageName := api.GetImageNameOrDefault(service, project.Name)
+			report := &api.BuildReport{
+				Service:      name,
+				Image:        imageName,
+				PreviousSize: s.imageSize(ctx, imageName),
+			}
+			reports[getServiceIndex(name)] = report
+			start := time.Now()
+			defer func() {
+				report.Duration = time.Since(start)
+				if d, ok := steps.solveDuration(); ok {
+					report.Duration = d
+				}
+				report.ImageID = builtDigests[getServiceIndex(name)]
+				report.Stages, report.Steps, report.CachedSteps = steps.summarize()
+				if err != nil {
+					// the image in the engine store is still the previous one
+					report.Failed = true
+					report.Error = err.Error()
+					return
+				}
+				report.Size = s.imageSize(ctx, imageName)
+			}()
+		}
+
+		if err := s.runServiceHooks(ctx, project, service, hookPreBuild, nil); err != nil {
+			return err
+		}
 
 		if !buildkitEnabled {
-			id, err := s.doBuildClassic(ctx, project, service, options)
+			id, err := s.doBuildClassic(ctx, project, service, options, steps)
 			if err != nil {
 				return err
 			}
 			builtDigests[getServiceIndex(name)] = id
+			if err := s.runServiceHooks(ctx, project, service, hookPostBuild, nil); err != nil {
+				return err
+			}
 
 			if options.Push {
 				return s.push(ctx, project, api.PushOptions{})
@@ -179,14 +239,22 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 		if err != nil {
 			return err
 		}
+		targets := map[string]build.Options{}
+		if b.Driver != "docker" {
+			// other drivers can't read the image of a service from the engine image store, so the services used as
+			// build contexts are built along as build targets
+			if err := s.serviceContextTargets(project, service, buildOptions, options, targets); err != nil {
+				return err
+			}
+		}
 
-		digest, err := s.doBuildBuildkit(ctx, name, buildOptions, w, nodes)
+		digest, err := s.doBuildBuildkit(ctx, name, buildOptions, targets, w, nodes, steps, options.Progress == progress.ModeJSON)
 		if err != nil {
 			return err
 		}
 		builtDigests[getServiceIndex(name)] = digest
 
-		return nil
+		return s.runServiceHooks(ctx, project, service, hookPostBuild, nil)
 	}, func(traversal *graphTraversal) {
 		traversal.maxConcurrency = s.maxConcurrency
 	})
@@ -199,6 +267,12 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 	}
 
 	if err != nil {
+		if reporting {
+			// the report tells which services failed to build and how far the others got
+			if errr := s.writeBuildReports(options, collectBuildReports(reports)); errr != nil {
+				logrus.Warnf("failed to write build report: %v", errr)
+			}
+		}
 		return nil, err
 	}
 
@@ -208,28 +282,70 @@ func (s *composeService) build(ctx context.Context, project *types.Project, opti
 			imageIDs[imageRef] = imageDigest
 		}
 	}
+
+	if reporting {
+		if err := s.writeBuildReports(options, collectBuildReports(reports)); err != nil {
+			return nil, err
+		}
+	}
 	return imageIDs, err
 }
 
-func (s *composeService) ensureImagesExists(ctx context.Context, project *types.Project, buildOpts *api.BuildOptions, quietPull bool) error {
+func (s *composeService) ensureImagesExists(ctx context.Context, project *types.Project, buildOpts *api.BuildOptions, pullOpts api.PullOptions) error {
+	_, err := s.ensureImages(ctx, project, buildOpts, pullOpts, false)
+	return err
+}
+
+// ensureImages pulls and builds the missing images of the project. With background set, images of services which can't
+// be built are pulled in background, and the returned imagePulls must be used to wait for them before creating containers.
+func (s *composeService) ensureImages(ctx context.Context, project *types.Project, buildOpts *api.BuildOptions, pullOpts api.PullOptions, background bool) (_ *imagePulls, err error) {
 	for name, service := range project.Services {
 		if service.Image == "" && service.Build == nil {
-			return fmt.Errorf("invalid service %q. Must specify either image or build", name)
+			return nil, fmt.Errorf("invalid service %q. Must specify either image or build", name)
 		}
 	}
 
 	images, err := s.getLocalImagesDigests(ctx, project)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var pulls *imagePulls
+	if background {
+		var inBackground []types.ServiceConfig
+		for _, service := range requiredPulls(project, images) {
+			if !isServiceImageToBuild(service, project.Services) {
+				inBackground = append(inBackground, service)
+			}
+		}
+		pulls = s.startPulls(ctx, project, inBackground, pullOpts)
+		defer func() {
+			if err != nil {
+				pulls.stop()
+			}
+		}()
 	}
 
 	err = tracing.SpanWrapFunc("project/pull", tracing.ProjectOptions(ctx, project),
 		func(ctx context.Context) error {
-			return s.pullRequiredImages(ctx, project, images, quietPull)
+			if pulls == nil {
+				return s.pullRequiredImages(ctx, project, images, pullOpts)
+			}
+			// only pull synchronously the images for services which can be built if the pull fails
+			buildable := project.Services.Filter(func(service types.ServiceConfig) bool {
+				_, inBackground := pulls.done[service.Name]
+				return !inBackground
+			})
+			err := s.pullRequiredImages(ctx, &types.Project{Name: project.Name, Services: buildable, Environment: project.Environment}, images, pullOpts)
+			for name, service := range buildable {
+				// images pinned by digest may have been pulled from a mirror
+				project.Services[name] = service
+			}
+			return err
 		},
 	)(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if buildOpts != nil {
@@ -247,7 +363,7 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 			},
 		)(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -263,7 +379,7 @@ func (s *composeService) ensureImagesExists(ctx context.Context, project *types.
 		}
 		project.Services[name] = service
 	}
-	return nil
+	return pulls, nil
 }
 
 func (s *composeService) getLocalImagesDigests(ctx context.Context, project *types.Project) (map[string]string, error) {
@@ -425,7 +541,7 @@ func (s *composeService) toBuildOptions(project *types.Project, service types.Se
 			ContextPath:      service.Build.Context,
 			DockerfileInline: service.Build.DockerfileInline,
 			DockerfilePath:   dockerFilePath(service.Build.Context, service.Build.Dockerfile),
-			NamedContexts:    toBuildContexts(service.Build.AdditionalContexts),
+			NamedContexts:    toBuildContexts(project, service.Build.AdditionalContexts),
 		},
 		CacheFrom:    pb.CreateCaches(cacheFrom),
 		CacheTo:      pb.CreateCaches(cacheTo),
@@ -502,6 +618,8 @@ func addSecretsConfig(project *types.Project, service types.ServiceConfig) (sess
 			id = secret.Target
 		}
 		switch {
+		case config.Extensions[api.SecretProviderExtension] != nil:
+			return nil, fmt.Errorf("build.secrets doesn't support secrets read with %s: %q", api.SecretProviderExtension, secret.Source)
 		case config.File != "":
 			sources = append(sources, secretsprovider.Source{
 				ID:       id,
@@ -540,14 +658,135 @@ func getImageBuildLabels(project *types.Project, service types.ServiceConfig) ty
 	return ret
 }
 
-func toBuildContexts(additionalContexts types.Mapping) map[string]build.NamedContext {
+func toBuildContexts(project *types.Project, additionalContexts types.Mapping) map[string]build.NamedContext {
 	namedContexts := map[string]build.NamedContext{}
 	for name, contextPath := range additionalContexts {
+		if ref, ok := buildContextService(contextPath); ok {
+			// referenced service has been built first, and its image loaded into the engine image store
+			contextPath = "docker-image://" + api.GetImageNameOrDefault(project.Services[ref], project.Name)
+		}
 		namedContexts[name] = build.NamedContext{Path: contextPath}
 	}
 	return namedContexts
 }
 
+// buildContextService returns the name of the service an additional build context refers to with `service:name`
+func buildContextService(contextPath string) (string, bool) {
+	name, ok := strings.CutPrefix(contextPath, types.ServicePrefix)
+	return name, ok && name != ""
+}
+
+// checkClassicServiceContexts checks no service uses another service as build context, as the classic builder doesn't
+// support additional build contexts
+func checkClassicServiceContexts(services map[string]serviceToBuild) error {
+	for name, service := range services {
+		if deps := buildDependencies(service.service); len(deps) > 0 {
+			return fmt.Errorf("service %q uses service %q as a build context, which the classic builder doesn't support, set DOCKER_BUILDKIT=1 to use BuildKit",
+				name, deps[0])
+		}
+	}
+	return nil
+}
+
+// serviceContextTargets sets the additional build contexts of opts referring to a service as build targets, and adds
+// the build options of these services to targets, so they are built along in the same BuildKit solve.
+func (s *composeService) serviceContextTargets(project *types.Project, service types.ServiceConfig, opts build.Options,
+	options api.BuildOptions, targets map[string]build.Options) error {
+	for key, contextPath := range service.Build.AdditionalContexts {
+		ref, ok := buildContextService(contextPath)
+		if !ok {
+			continue
+		}
+		opts.Inputs.NamedContexts[key] = build.NamedContext{Path: "target:" + ref}
+		if _, ok := targets[ref]; ok {
+			continue
+		}
+		dependency := project.Services[ref]
+		targetOpts, err := s.toBuildOptions(project, dependency, options)
+		if err != nil {
+			return err
+		}
+		// the image of the service has been built and exported on its own, only the build result is used here
+		targetOpts.Exports = nil
+		targets[ref] = targetOpts
+		if err := s.serviceContextTargets(project, dependency, targetOpts, options, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDependencies returns the services a service build relies on as additional build contexts
+func buildDependencies(service types.ServiceConfig) []string {
+	if service.Build == nil {
+		return nil
+	}
+	var deps []string
+	for _, contextPath := range service.Build.AdditionalContexts {
+		if name, ok := buildContextService(contextPath); ok && !utils.StringContains(deps, name) {
+			deps = append(deps, name)
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
+
+// withBuildDependencies returns a copy of the project with services depending on the services their build refers to,
+// so that the dependency graph can be used to order builds and detect cycles
+func withBuildDependencies(project *types.Project) (*types.Project, error) {
+	buildProject := *project
+	buildProject.Services = types.Services{}
+	for name, service := range project.Services {
+		deps := buildDependencies(service)
+		dependsOn := types.DependsOnConfig{}
+		for dep, config := range service.DependsOn {
+			dependsOn[dep] = config
+		}
+		for _, dep := range deps {
+			if _, ok := project.Services[dep]; !ok {
+				return nil, fmt.Errorf("service %q build refers to undefined service %q", name, dep)
+			}
+			dependsOn[dep] = types.ServiceDependency{
+				Condition: types.ServiceConditionStarted,
+				Required:  true,
+			}
+		}
+		service.DependsOn = dependsOn
+		buildProject.Services[name] = service
+	}
+	if _, err := NewGraph(&buildProject, ServiceStopped); err != nil {
+		return nil, fmt.Errorf("invalid build dependencies: %w", err)
+	}
+	return &buildProject, nil
+}
+
+// addReferencedBuilds adds to the services to be built the ones their build refers to, unless their image is already available
+func addReferencedBuilds(project *types.Project, serviceToBeBuild map[string]serviceToBuild, localImages map[string]string) {
+	var pending []string
+	for name := range serviceToBeBuild {
+		pending = append(pending, name)
+	}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		for _, dep := range buildDependencies(serviceToBeBuild[name].service) {
+			if _, ok := serviceToBeBuild[dep]; ok {
+				continue
+			}
+			service := project.Services[dep]
+			if service.Build == nil {
+				continue
+			}
+			_, localImagePresent := localImages[api.GetImageNameOrDefault(service, project.Name)]
+			if localImagePresent && service.PullPolicy != types.PullPolicyBuild {
+				continue
+			}
+			serviceToBeBuild[dep] = serviceToBuild{name: dep, service: service}
+			pending = append(pending, dep)
+		}
+	}
+}
+
 func parsePlatforms(service types.ServiceConfig) ([]specs.Platform, error) {
 	if service.Build == nil || len(service.Build.Platforms) == 0 {
 		return nil, nil
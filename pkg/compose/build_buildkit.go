@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/sha1"
 	"fmt"
+	"maps"
 
 	"github.com/docker/buildx/build"
 	"github.com/docker/buildx/builder"
@@ -30,11 +31,16 @@ import (
 	"github.com/docker/buildx/util/confutil"
 	"github.com/docker/buildx/util/dockerutil"
 	buildx "github.com/docker/buildx/util/progress"
+	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/moby/buildkit/client"
 )
 
-func (s *composeService) doBuildBuildkit(ctx context.Context, service string, opts build.Options, p *buildx.Printer, nodes []builder.Node) (string, error) {
+// doBuildBuildkit builds a service image with BuildKit, along with the targets its build contexts refer to. If steps
+// is set, the build steps and the summary of the solve are recorded into it. If events is set, the build steps are
+// reported as progress events.
+func (s *composeService) doBuildBuildkit(ctx context.Context, service string, opts build.Options, targets map[string]build.Options,
+	p *buildx.Printer, nodes []builder.Node, steps *buildSteps, events bool) (string, error) {
 	var (
 		response map[string]*client.SolveResponse
 		err      error
@@ -42,27 +48,42 @@ func (s *composeService) doBuildBuildkit(ctx context.Context, service string, op
 	if s.dryRun {
 		response = s.dryRunBuildResponse(ctx, service, opts)
 	} else {
+		var w buildx.Writer = buildx.WithPrefix(p, service, true)
+		pw := progress.ContextWriter(ctx)
+		if events {
+			pw.Event(progress.Event{ID: service, Kind: api.ProgressKindImage, Status: progress.Working, Text: "Building"})
+			w = &buildkitProgressEvents{Writer: w, events: pw, service: service}
+		}
+		if steps != nil {
+			w = &buildkitStepsRecorder{Writer: w, steps: steps}
+		}
+		builds := map[string]build.Options{service: opts}
+		maps.Copy(builds, targets)
 		response, err = build.Build(ctx, nodes,
-			map[string]build.Options{service: opts},
+			builds,
 			dockerutil.NewClient(s.dockerCli),
 			confutil.ConfigDir(s.dockerCli),
-			buildx.WithPrefix(p, service, true))
+			w)
+		if events {
+			if err != nil {
+				pw.Event(progress.Event{ID: service, Kind: api.ProgressKindImage, Status: progress.Error, Text: "Error", StatusText: err.Error()})
+			} else {
+				pw.Event(progress.Event{ID: service, Kind: api.ProgressKindImage, Status: progress.Done, Text: "Built"})
+			}
+		}
 		if err != nil {
 			return "", WrapCategorisedComposeError(err, BuildFailure)
 		}
+		if steps != nil {
+			recordSolveResponse(ctx, nodes, response, steps)
+		}
 	}
 
-	for _, img := range response {
-		if img == nil || len(img.ExporterResponse) == 0 {
-			continue
+	if img := response[service]; img != nil {
+		if digest, ok := img.ExporterResponse["containerimage.digest"]; ok {
+			return digest, nil
 		}
-		digest, ok := img.ExporterResponse["containerimage.digest"]
-		if !ok {
-			continue
-		}
-		return digest, nil
 	}
-
 	return "", fmt.Errorf("buildkit response is missing expected result for %s", service)
 }
 
@@ -72,16 +93,19 @@ func (s composeService) dryRunBuildResponse(ctx context.Context, name string, op
 	dryRunUUID := fmt.Sprintf("dryRun-%x", sha1.Sum([]byte(name)))
 	w.Event(progress.Event{
 		ID:     " ",
+		Kind:   api.ProgressKindBuildStep,
 		Status: progress.Done,
 		Text:   fmt.Sprintf("build service %s", name),
 	})
 	w.Event(progress.Event{
 		ID:     "==>",
+		Kind:   api.ProgressKindBuildStep,
 		Status: progress.Done,
 		Text:   fmt.Sprintf("==> writing image %s", dryRunUUID),
 	})
 	w.Event(progress.Event{
 		ID:     "==> ==>",
+		Kind:   api.ProgressKindBuildStep,
 		Status: progress.Done,
 		Text:   fmt.Sprintf(`naming to %s`, options.Tags[0]),
 	})
@@ -90,3 +114,36 @@ func (s composeService) dryRunBuildResponse(ctx context.Context, name string, op
 	}}
 	return buildResponse
 }
+
+// buildkitProgressEvents reports the vertexes of a BuildKit solve as build step events of the service image
+type buildkitProgressEvents struct {
+	buildx.Writer
+	events  progress.Writer
+	service string
+}
+
+func (r *buildkitProgressEvents) Write(status *client.SolveStatus) {
+	for _, v := range status.Vertexes {
+		event := progress.Event{
+			ID:       v.Digest.String(),
+			ParentID: r.service,
+			Kind:     api.ProgressKindBuildStep,
+			Text:     v.Name,
+			Status:   progress.Working,
+		}
+		switch {
+		case v.Error != "":
+			event.Status = progress.Error
+			event.StatusText = v.Error
+		case v.Cached:
+			event.Status = progress.Done
+			event.StatusText = "CACHED"
+		case v.Completed != nil:
+			event.Status = progress.Done
+		}
+		r.events.Event(event)
+	}
+	r.Writer.Write(status)
+}
+
+var _ buildx.Writer = &buildkitProgressEvents{}
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/docker/cli/cli/command"
 
@@ -48,8 +49,10 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// doBuildClassic builds a service image with the legacy builder. If steps is set, the build steps are recorded into it.
+//
 //nolint:gocyclo
-func (s *composeService) doBuildClassic(ctx context.Context, project *types.Project, service types.ServiceConfig, options api.BuildOptions) (string, error) {
+func (s *composeService) doBuildClassic(ctx context.Context, project *types.Project, service types.ServiceConfig, options api.BuildOptions, steps *buildSteps) (string, error) {
 	var (
 		buildCtx      io.ReadCloser
 		dockerfileCtx io.ReadCloser
@@ -188,7 +191,32 @@ func (s *composeService) doBuildClassic(ctx context.Context, project *types.Proj
 		}
 	}
 
-	err = jsonmessage.DisplayJSONMessagesStream(response.Body, buildBuff, progBuff.FD(), true, aux)
+	var stream io.Reader = response.Body
+	if steps != nil {
+		recorder := &classicStepsRecorder{steps: steps}
+		pr, pw := io.Pipe()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			decoder := json.NewDecoder(pr)
+			for {
+				var msg jsonmessage.JSONMessage
+				if err := decoder.Decode(&msg); err != nil {
+					_, _ = io.Copy(io.Discard, pr)
+					return
+				}
+				recorder.stream(msg.Stream, time.Now())
+			}
+		}()
+		defer func() {
+			_ = pw.Close()
+			<-done
+			recorder.complete(time.Now())
+		}()
+		stream = io.TeeReader(response.Body, pw)
+	}
+
+	err = jsonmessage.DisplayJSONMessagesStream(stream, buildBuff, progBuff.FD(), true, aux)
 	if err != nil {
 		var jerr *jsonmessage.JSONError
 		if errors.As(err, &jerr) {
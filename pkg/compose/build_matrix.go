@@ -0,0 +1,204 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/compose/v2/pkg/utils"
+)
+
+// buildVariant is the build of a service for one of the target and platform combinations of a build matrix
+type buildVariant struct {
+	name     string
+	service  string
+	target   string
+	platform string
+	image    string
+}
+
+func (s *composeService) buildMatrix(ctx context.Context, project *types.Project, options api.BuildOptions) error {
+	matrix, variants, err := expandBuildMatrix(project, options)
+	if err != nil {
+		return err
+	}
+	var imageIDs map[string]string
+	err = progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		options.Services = nil
+		for _, v := range variants {
+			options.Services = append(options.Services, v.name)
+		}
+		options.Deps = false
+		imageIDs, err = s.build(ctx, matrix, options, nil)
+		return err
+	}, s.stdinfo(), "Building")
+	if err != nil {
+		return err
+	}
+	if !options.Quiet {
+		printBuildMatrix(s.stdout(), variants, imageIDs)
+	}
+	return nil
+}
+
+// expandBuildMatrix returns a copy of the project where the selected services are replaced by a variant for each
+// combination of the targets and platforms set by the build options, and the list of those variants
+func expandBuildMatrix(project *types.Project, options api.BuildOptions) (*types.Project, []buildVariant, error) {
+	var policy types.DependencyOption = types.IgnoreDependencies
+	if options.Deps {
+		policy = types.IncludeDependencies
+	}
+	var selected []string
+	err := project.ForEachService(options.Services, func(name string, service *types.ServiceConfig) error {
+		if service.Build != nil {
+			selected = append(selected, name)
+		}
+		return nil
+	}, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Strings(selected)
+
+	targets := options.Targets
+	if len(targets) == 0 {
+		targets = []string{""}
+	}
+	platforms := options.Platforms
+	if len(platforms) == 0 {
+		platforms = []string{""}
+	}
+
+	matrix := *project
+	matrix.Services = types.Services{}
+	for name, service := range project.Services {
+		if !utils.StringContains(selected, name) {
+			// build order only relies on additional contexts, runtime dependencies don't matter here
+			service.DependsOn = nil
+			matrix.Services[name] = service
+		}
+	}
+
+	// a single variant per service is only built for another target or platform, it keeps the image of the service
+	single := len(targets) == 1 && len(platforms) == 1
+
+	var variants []buildVariant
+	expanded := map[string]map[string]string{}
+	for _, name := range selected {
+		service := project.Services[name]
+		expanded[name] = map[string]string{}
+		for _, target := range targets {
+			for _, platform := range platforms {
+				if platform != "" && len(service.Build.Platforms) > 0 && !utils.StringContains(service.Build.Platforms, platform) {
+					return nil, nil, fmt.Errorf("service %q build configuration does not support platform: %s", name, platform)
+				}
+				suffix := variantSuffix(target, platform)
+				variant := buildVariant{
+					name:     name + "-" + suffix,
+					service:  name,
+					target:   target,
+					platform: platform,
+					image:    api.GetImageNameOrDefault(service, project.Name),
+				}
+				if !single {
+					variant.image = variantImage(variant.image, suffix)
+				}
+				if _, ok := project.Services[variant.name]; ok {
+					return nil, nil, fmt.Errorf("build variant %q of service %q conflicts with an existing service", variant.name, name)
+				}
+				expanded[name][suffix] = variant.name
+				variants = append(variants, variant)
+			}
+		}
+	}
+
+	for _, variant := range variants {
+		service := project.Services[variant.service]
+		service.Name = variant.name
+		service.Image = variant.image
+		service.DependsOn = nil
+		build := *service.Build
+		if variant.target != "" {
+			build.Target = variant.target
+		}
+		if variant.platform != "" {
+			service.Platform = variant.platform
+			build.Platforms = []string{variant.platform}
+		}
+		suffix := variantSuffix(variant.target, variant.platform)
+		if !single {
+			// each variant is tagged on its own, otherwise the last variant built would get the tags of all
+			build.Tags = nil
+			for _, tag := range service.Build.Tags {
+				build.Tags = append(build.Tags, variantImage(tag, suffix))
+			}
+		}
+		// a variant built from another selected service refers to the same variant of it
+		build.AdditionalContexts = types.Mapping{}
+		for key, contextPath := range service.Build.AdditionalContexts {
+			if ref, ok := buildContextService(contextPath); ok && expanded[ref] != nil {
+				contextPath = types.ServicePrefix + expanded[ref][suffix]
+			}
+			build.AdditionalContexts[key] = contextPath
+		}
+		service.Build = &build
+		matrix.Services[variant.name] = service
+	}
+	return &matrix, variants, nil
+}
+
+// variantSuffix returns the suffix identifying a target and platform combination, like `test-linux-arm64`
+func variantSuffix(target, platform string) string {
+	var parts []string
+	if target != "" {
+		parts = append(parts, target)
+	}
+	if platform != "" {
+		parts = append(parts, strings.ReplaceAll(platform, "/", "-"))
+	}
+	return strings.Join(parts, "-")
+}
+
+// variantImage tags an image for a build variant, appending the variant suffix to the image tag if any
+func variantImage(image, suffix string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name, tag := image, ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	if tag == "" {
+		return name + ":" + suffix
+	}
+	return name + ":" + tag + "-" + suffix
+}
+
+func printBuildMatrix(out io.Writer, variants []buildVariant, imageIDs map[string]string) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tTARGET\tPLATFORM\tIMAGE\tIMAGE ID")
+	for _, v := range variants {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", v.service, utils.StringOrDash(v.target), utils.StringOrDash(v.platform), v.image, utils.StringOrDash(imageIDs[v.image]))
+	}
+	_ = w.Flush()
+}
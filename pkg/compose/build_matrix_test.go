@@ -0,0 +1,114 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"gotest.tools/v3/assert"
+)
+
+func TestVariantImage(t *testing.T) {
+	assert.Equal(t, variantImage("myproject-app", "test"), "myproject-app:test")
+	assert.Equal(t, variantImage("registry:5000/app:1.0", "prod-linux-arm64"), "registry:5000/app:1.0-prod-linux-arm64")
+	assert.Equal(t, variantImage("registry:5000/app", "linux-amd64"), "registry:5000/app:linux-amd64")
+	assert.Equal(t, variantImage("app:1.0@sha256:abcd", "test"), "app:1.0-test")
+}
+
+func TestExpandBuildMatrix(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"base": {Name: "base", Build: buildWithContexts(nil)},
+			"app": {
+				Name:      "app",
+				Image:     "app:1.0",
+				Build:     buildWithContexts(types.Mapping{"base": "service:base"}),
+				DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionStarted, Required: true}},
+			},
+			"db": {Name: "db", Image: "postgres"},
+		},
+	}
+	matrix, variants, err := expandBuildMatrix(project, api.BuildOptions{
+		Platforms: []string{"linux/amd64", "linux/arm64"},
+		Targets:   []string{"test", "prod"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(variants), 8)
+	assert.Check(t, len(matrix.Services) == 9)
+
+	app := matrix.Services["app-prod-linux-arm64"]
+	assert.Equal(t, app.Image, "app:1.0-prod-linux-arm64")
+	assert.Equal(t, app.Platform, "linux/arm64")
+	assert.Equal(t, app.Build.Target, "prod")
+	assert.DeepEqual(t, app.Build.Platforms, types.StringList{"linux/arm64"})
+	assert.Equal(t, app.Build.AdditionalContexts["base"], "service:base-prod-linux-arm64")
+	assert.Check(t, app.DependsOn == nil)
+
+	_, err = withBuildDependencies(matrix)
+	assert.NilError(t, err)
+
+	// original service isn't altered
+	assert.Equal(t, project.Services["app"].Build.AdditionalContexts["base"], "service:base")
+	assert.Equal(t, project.Services["app"].Build.Target, "")
+
+	var out bytes.Buffer
+	printBuildMatrix(&out, variants[:1], map[string]string{"app:1.0-test-linux-amd64": "sha256:1234"})
+	assert.Equal(t, out.String(), `SERVICE   TARGET   PLATFORM      IMAGE                      IMAGE ID
+app       test     linux/amd64   app:1.0-test-linux-amd64   sha256:1234
+`)
+}
+
+func TestExpandBuildMatrixUnsupportedPlatform(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"app": {Name: "app", Build: &types.BuildConfig{Context: ".", Platforms: []string{"linux/amd64"}}},
+		},
+	}
+	_, _, err := expandBuildMatrix(project, api.BuildOptions{Platforms: []string{"linux/arm64"}})
+	assert.ErrorContains(t, err, `service "app" build configuration does not support platform: linux/arm64`)
+}
+
+func TestExpandBuildMatrixTags(t *testing.T) {
+	build := &types.BuildConfig{Context: ".", Tags: []string{"registry/app:1.0", "registry/app:latest"}}
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"app": {Name: "app", Image: "app:1.0", Build: build},
+		},
+	}
+	matrix, variants, err := expandBuildMatrix(project, api.BuildOptions{Platforms: []string{"linux/amd64", "linux/arm64"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(variants), 2)
+	assert.DeepEqual(t, matrix.Services["app-linux-amd64"].Build.Tags, types.StringList{"registry/app:1.0-linux-amd64", "registry/app:latest-linux-amd64"})
+	assert.DeepEqual(t, matrix.Services["app-linux-arm64"].Build.Tags, types.StringList{"registry/app:1.0-linux-arm64", "registry/app:latest-linux-arm64"})
+	assert.DeepEqual(t, project.Services["app"].Build.Tags, types.StringList{"registry/app:1.0", "registry/app:latest"})
+
+	// a single target or platform doesn't rename the image
+	matrix, variants, err = expandBuildMatrix(project, api.BuildOptions{Targets: []string{"prod"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(variants), 1)
+	assert.Equal(t, variants[0].image, "app:1.0")
+	app := matrix.Services["app-prod"]
+	assert.Equal(t, app.Image, "app:1.0")
+	assert.Equal(t, app.Build.Target, "prod")
+	assert.DeepEqual(t, app.Build.Tags, types.StringList{"registry/app:1.0", "registry/app:latest"})
+}
@@ -0,0 +1,385 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/buildx/builder"
+	buildx "github.com/docker/buildx/util/progress"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/go-units"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultStage is the name reported for the steps of an unnamed single stage build
+const defaultStage = "default"
+
+// buildStep is a Dockerfile instruction run by a build
+type buildStep struct {
+	stage     string
+	started   time.Time
+	completed time.Time
+	cached    bool
+}
+
+// buildSteps collects the steps of a service build, as reported by the builder
+type buildSteps struct {
+	mu    sync.Mutex
+	steps map[string]*buildStep
+	order []string
+	// solved is the summary BuildKit recorded in its build history, if any
+	solved *solveSummary
+}
+
+// solveSummary is the summary of a BuildKit solve, as recorded in the builder build history
+type solveSummary struct {
+	duration time.Duration
+	steps    int
+	cached   int
+}
+
+func newBuildSteps() *buildSteps {
+	return &buildSteps{steps: map[string]*buildStep{}}
+}
+
+func (b *buildSteps) update(id string, fn func(step *buildStep)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	step, ok := b.steps[id]
+	if !ok {
+		step = &buildStep{}
+		b.steps[id] = step
+		b.order = append(b.order, id)
+	}
+	fn(step)
+}
+
+// setSolveRecord sets the steps and duration of the build from the BuildKit history record of the solve
+func (b *buildSteps) setSolveRecord(record *controlapi.BuildHistoryRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	summary := &solveSummary{
+		steps:  int(record.NumTotalSteps),
+		cached: int(record.NumCachedSteps),
+	}
+	if record.CreatedAt != nil && record.CompletedAt != nil {
+		summary.duration = record.CompletedAt.Sub(*record.CreatedAt)
+	}
+	b.solved = summary
+}
+
+// solveDuration returns the duration of the solve recorded by BuildKit, if any
+func (b *buildSteps) solveDuration() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.solved == nil || b.solved.duration == 0 {
+		return 0, false
+	}
+	return b.solved.duration, true
+}
+
+// summarize aggregates the recorded steps by stage, in the order stages started. The total and cached
+// steps come from the BuildKit history record when available, as progress only reports the steps
+// which have been displayed.
+func (b *buildSteps) summarize() ([]api.BuildStageReport, int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var (
+		stages        []api.BuildStageReport
+		starts, ends  []time.Time
+		index         = map[string]int{}
+		total, cached int
+	)
+	for _, id := range b.order {
+		step := b.steps[id]
+		i, ok := index[step.stage]
+		if !ok {
+			i = len(stages)
+			index[step.stage] = i
+			stages = append(stages, api.BuildStageReport{Name: step.stage})
+			starts = append(starts, step.started)
+			ends = append(ends, step.completed)
+		}
+		stages[i].Steps++
+		total++
+		if step.cached {
+			stages[i].CachedSteps++
+			cached++
+		}
+		if !step.started.IsZero() && (starts[i].IsZero() || step.started.Before(starts[i])) {
+			starts[i] = step.started
+		}
+		if step.completed.After(ends[i]) {
+			ends[i] = step.completed
+		}
+	}
+	for i := range stages {
+		if !starts[i].IsZero() && ends[i].After(starts[i]) {
+			stages[i].Duration = ends[i].Sub(starts[i])
+		}
+	}
+	if b.solved != nil {
+		total, cached = b.solved.steps, b.solved.cached
+	}
+	return stages, total, cached
+}
+
+// recordSolveResponse reads the summary of the builds in the response from the builder build history. BuildKit
+// sets the history reference of a build in the exporter response, but only keeps history if the builder
+// supports it, otherwise the report falls back to the steps reported by progress.
+func recordSolveResponse(ctx context.Context, nodes []builder.Node, response map[string]*client.SolveResponse, steps *buildSteps) {
+	for _, res := range response {
+		if res == nil {
+			continue
+		}
+		ref, ok := res.ExporterResponse["buildx.build.ref"]
+		if !ok {
+			continue
+		}
+		record, err := buildHistoryRecord(ctx, nodes, ref)
+		if err != nil {
+			logrus.Debugf("failed to read build history record %s: %v", ref, err)
+			continue
+		}
+		if record != nil {
+			steps.setSolveRecord(record)
+		}
+	}
+}
+
+// buildHistoryRecord returns the history record of the build referenced as `builder/node/ref`, nil if there is none
+func buildHistoryRecord(ctx context.Context, nodes []builder.Node, buildRef string) (*controlapi.BuildHistoryRecord, error) {
+	parts := strings.SplitN(buildRef, "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid build reference %q", buildRef)
+	}
+	for _, node := range nodes {
+		if node.Name != parts[1] || node.Driver == nil {
+			continue
+		}
+		c, err := node.Driver.Client(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cl, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+			Ref:       parts[2],
+			EarlyExit: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var record *controlapi.BuildHistoryRecord
+		for {
+			ev, err := cl.Recv()
+			if errors.Is(err, io.EOF) {
+				return record, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if ev.Record != nil {
+				record = ev.Record
+			}
+		}
+	}
+	return nil, nil
+}
+
+// buildkitStepRe matches the prefix BuildKit sets on Dockerfile instructions vertexes, like `[builder 2/5]`
+var buildkitStepRe = regexp.MustCompile(`^\[(?:(.+) )?\d+/\d+\] `)
+
+// buildkitStepsRecorder records the steps reported by BuildKit while forwarding them to the progress writer
+type buildkitStepsRecorder struct {
+	buildx.Writer
+	steps *buildSteps
+}
+
+func (r *buildkitStepsRecorder) Write(status *client.SolveStatus) {
+	for _, v := range status.Vertexes {
+		match := buildkitStepRe.FindStringSubmatch(v.Name)
+		if match == nil {
+			// not a Dockerfile instruction, e.g. `[internal] load build context`
+			continue
+		}
+		stage := match[1]
+		if stage == "" {
+			stage = defaultStage
+		}
+		vertex := *v
+		r.steps.update(vertex.Digest.String(), func(step *buildStep) {
+			step.stage = stage
+			step.cached = step.cached || vertex.Cached
+			if vertex.Started != nil && step.started.IsZero() {
+				step.started = *vertex.Started
+			}
+			if vertex.Completed != nil {
+				step.completed = *vertex.Completed
+			}
+		})
+	}
+	r.Writer.Write(status)
+}
+
+var _ buildx.Writer = &buildkitStepsRecorder{}
+
+var (
+	// classicStepRe matches the `Step 2/5 : RUN make` lines of the classic builder output
+	classicStepRe = regexp.MustCompile(`^Step (\d+)/\d+ : (.*)$`)
+	// classicFromRe matches a FROM instruction and captures its optional stage name
+	classicFromRe = regexp.MustCompile(`(?i)^FROM\s+\S+(?:\s+AS\s+(\S+))?`)
+)
+
+// classicStepsRecorder records the steps reported by the classic builder output stream. The classic builder
+// aux messages only carry the built image ID, so steps and cache hits can only be read from the stream.
+// As it doesn't report when a step completes, each step is considered completed when the next one starts.
+type classicStepsRecorder struct {
+	steps   *buildSteps
+	current string
+	stage   string
+	stages  int
+}
+
+func (r *classicStepsRecorder) stream(text string, at time.Time) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if match := classicStepRe.FindStringSubmatch(line); match != nil {
+			r.complete(at)
+			if from := classicFromRe.FindStringSubmatch(match[2]); from != nil {
+				r.stage = from[1]
+				if r.stage == "" {
+					r.stage = fmt.Sprintf("stage-%d", r.stages)
+				}
+				r.stages++
+			}
+			r.current = match[1]
+			stage := r.stage
+			r.steps.update(r.current, func(step *buildStep) {
+				step.stage = stage
+				step.started = at
+			})
+			continue
+		}
+		if line == "---> Using cache" && r.current != "" {
+			r.steps.update(r.current, func(step *buildStep) {
+				step.cached = true
+			})
+		}
+	}
+}
+
+// complete marks the current step as completed
+func (r *classicStepsRecorder) complete(at time.Time) {
+	if r.current == "" {
+		return
+	}
+	r.steps.update(r.current, func(step *buildStep) {
+		step.completed = at
+	})
+}
+
+// imageSize returns the size of an image, 0 if it doesn't exist in the engine image store
+func (s *composeService) imageSize(ctx context.Context, ref string) int64 {
+	inspect, _, err := s.apiClient().ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return 0
+	}
+	return inspect.Size
+}
+
+// collectBuildReports returns the reports of the services which have been built, or failed to
+func collectBuildReports(reports []*api.BuildReport) []api.BuildReport {
+	var built []api.BuildReport
+	for _, report := range reports {
+		if report != nil {
+			built = append(built, *report)
+		}
+	}
+	return built
+}
+
+// writeBuildReports prints and/or saves the build reports as requested by the build options
+func (s *composeService) writeBuildReports(options api.BuildOptions, reports []api.BuildReport) error {
+	if options.Summary {
+		printBuildReports(s.stdout(), reports)
+	}
+	if options.Report == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(options.Report, b, 0o644)
+}
+
+func printBuildReports(out io.Writer, reports []api.BuildReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "SERVICE\tDURATION\tCACHED\tSIZE\tSIZE CHANGE")
+	for _, r := range reports {
+		service := r.Service
+		if r.Failed {
+			service += " (failed)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", service, r.Duration.Round(100*time.Millisecond),
+			cacheRatio(r.CachedSteps, r.Steps), humanSize(r.Size), sizeChange(r.Size, r.PreviousSize))
+		for _, stage := range r.Stages {
+			_, _ = fmt.Fprintf(w, "  %s\t%s\t%s\t\t\n", stage.Name, stage.Duration.Round(100*time.Millisecond),
+				cacheRatio(stage.CachedSteps, stage.Steps))
+		}
+	}
+	_ = w.Flush()
+}
+
+func cacheRatio(cached, steps int) string {
+	if steps == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", cached, steps, cached*100/steps)
+}
+
+func humanSize(size int64) string {
+	if size == 0 {
+		return "-"
+	}
+	return units.HumanSizeWithPrecision(float64(size), 3)
+}
+
+func sizeChange(size, previous int64) string {
+	switch {
+	case size == 0:
+		return "-"
+	case previous == 0:
+		return "new"
+	case size >= previous:
+		return "+" + units.HumanSizeWithPrecision(float64(size-previous), 3)
+	default:
+		return "-" + units.HumanSizeWithPrecision(float64(previous-size), 3)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	buildx "github.com/docker/buildx/util/progress"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+	moby "github.com/docker/docker/api/types"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+type discardWriter struct {
+	buildx.Writer
+	written int
+}
+
+func (d *discardWriter) Write(*client.SolveStatus) {
+	d.written++
+}
+
+func TestBuildkitStepsRecorder(t *testing.T) {
+	steps := newBuildSteps()
+	next := &discardWriter{}
+	recorder := &buildkitStepsRecorder{Writer: next, steps: steps}
+
+	t0 := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := t0.Add(d)
+		return &t
+	}
+	recorder.Write(&client.SolveStatus{Vertexes: []*client.Vertex{
+		{Digest: digest.FromString("internal"), Name: "[internal] load build context", Started: at(0), Completed: at(time.Second)},
+		{Digest: digest.FromString("b1"), Name: "[builder 1/2] FROM golang", Started: at(0), Completed: at(time.Second), Cached: true},
+		{Digest: digest.FromString("b2"), Name: "[builder 2/2] RUN go build", Started: at(time.Second)},
+	}})
+	recorder.Write(&client.SolveStatus{Vertexes: []*client.Vertex{
+		{Digest: digest.FromString("b2"), Name: "[builder 2/2] RUN go build", Started: at(time.Second), Completed: at(5 * time.Second)},
+		{Digest: digest.FromString("s1"), Name: "[stage-1 1/1] COPY --from=builder /app /app", Started: at(5 * time.Second), Completed: at(6 * time.Second)},
+	}})
+	assert.Equal(t, next.written, 2)
+
+	stages, total, cached := steps.summarize()
+	assert.Equal(t, total, 3)
+	assert.Equal(t, cached, 1)
+	assert.DeepEqual(t, stages, []api.BuildStageReport{
+		{Name: "builder", Duration: 5 * time.Second, Steps: 2, CachedSteps: 1},
+		{Name: "stage-1", Duration: time.Second, Steps: 1},
+	})
+}
+
+type eventsRecorder struct {
+	progress.Writer
+	events []progress.Event
+}
+
+func (r *eventsRecorder) Event(e progress.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestBuildkitProgressEvents(t *testing.T) {
+	next := &discardWriter{}
+	recorder := &eventsRecorder{}
+	w := &buildkitProgressEvents{Writer: next, events: recorder, service: "web"}
+
+	now := time.Now()
+	w.Write(&client.SolveStatus{Vertexes: []*client.Vertex{
+		{Digest: digest.FromString("b1"), Name: "[1/2] FROM alpine", Completed: &now, Cached: true},
+		{Digest: digest.FromString("b2"), Name: "[2/2] RUN make", Started: &now},
+		{Digest: digest.FromString("b3"), Name: "[2/2] RUN test", Error: "exit code: 2"},
+	}})
+	assert.Equal(t, next.written, 1)
+	assert.DeepEqual(t, recorder.events, []progress.Event{
+		{ID: digest.FromString("b1").String(), ParentID: "web", Kind: api.ProgressKindBuildStep, Text: "[1/2] FROM alpine", Status: progress.Done, StatusText: "CACHED"},
+		{ID: digest.FromString("b2").String(), ParentID: "web", Kind: api.ProgressKindBuildStep, Text: "[2/2] RUN make", Status: progress.Working},
+		{ID: digest.FromString("b3").String(), ParentID: "web", Kind: api.ProgressKindBuildStep, Text: "[2/2] RUN test", Status: progress.Error, StatusText: "exit code: 2"},
+	}, cmpopts.IgnoreUnexported(progress.Event{}))
+}
+
+func TestBuildStepsSolveRecord(t *testing.T) {
+	steps := newBuildSteps()
+	t0 := time.Now()
+	started, completed := t0.Add(time.Second), t0.Add(4*time.Second)
+	steps.update("b1", func(step *buildStep) {
+		step.stage = "builder"
+		step.started = t0
+		step.completed = completed
+	})
+	_, ok := steps.solveDuration()
+	assert.Assert(t, !ok)
+
+	// progress may not report every step, the history record is authoritative for the totals
+	steps.setSolveRecord(&controlapi.BuildHistoryRecord{
+		CreatedAt:      &started,
+		CompletedAt:    &completed,
+		NumTotalSteps:  6,
+		NumCachedSteps: 4,
+	})
+	stages, total, cached := steps.summarize()
+	assert.Equal(t, total, 6)
+	assert.Equal(t, cached, 4)
+	assert.Equal(t, len(stages), 1)
+	duration, ok := steps.solveDuration()
+	assert.Assert(t, ok)
+	assert.Equal(t, duration, 3*time.Second)
+}
+
+func TestClassicStepsRecorder(t *testing.T) {
+	steps := newBuildSteps()
+	recorder := &classicStepsRecorder{steps: steps}
+
+	t0 := time.Now()
+	recorder.stream("Step 1/4 : FROM alpine AS base\n", t0)
+	recorder.stream(" ---> Using cache\n", t0)
+	recorder.stream("Step 2/4 : RUN apk add curl\n", t0.Add(time.Second))
+	recorder.stream(" ---> Running in 1234\n", t0.Add(time.Second))
+	recorder.stream("Step 3/4 : FROM base\n", t0.Add(3*time.Second))
+	recorder.stream("Step 4/4 : CMD [\"curl\"]\n", t0.Add(4*time.Second))
+	recorder.complete(t0.Add(5 * time.Second))
+
+	stages, total, cached := steps.summarize()
+	assert.Equal(t, total, 4)
+	assert.Equal(t, cached, 1)
+	assert.DeepEqual(t, stages, []api.BuildStageReport{
+		{Name: "base", Duration: 3 * time.Second, Steps: 2, CachedSteps: 1},
+		{Name: "stage-1", Duration: 2 * time.Second, Steps: 2},
+	})
+}
+
+func TestPrintBuildReports(t *testing.T) {
+	var b bytes.Buffer
+	printBuildReports(&b, []api.BuildReport{
+		{
+			Service:      "api",
+			Duration:     12 * time.Second,
+			Steps:        4,
+			CachedSteps:  3,
+			Size:         2_000_000,
+			PreviousSize: 1_000_000,
+			Stages:       []api.BuildStageReport{{Name: "builder", Duration: 10 * time.Second, Steps: 4, CachedSteps: 3}},
+		},
+	})
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, strings.Join(strings.Fields(lines[1]), " "), "api 12s 3/4 (75%) 2MB +1MB")
+	assert.Equal(t, strings.Join(strings.Fields(lines[2]), " "), "builder 10s 3/4 (75%)")
+
+	b.Reset()
+	printBuildReports(&b, []api.BuildReport{{Service: "api", Duration: 2 * time.Second, Failed: true}})
+	lines = strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Equal(t, strings.Join(strings.Fields(lines[1]), " "), "api (failed) 2s - - -")
+}
+
+func TestBuildReportOnFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().BuildKitEnabled().Return(false, nil)
+	apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "test-api").Return(moby.ImageInspect{}, nil, errors.New("no such image")).AnyTimes()
+	s := &composeService{dockerCli: cli}
+
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"api": {Name: "api", Build: &types.BuildConfig{Context: t.TempDir(), Privileged: true}},
+		},
+	}
+	report := filepath.Join(t.TempDir(), "report.json")
+	_, err := s.build(context.Background(), project, api.BuildOptions{Report: report}, nil)
+	assert.ErrorContains(t, err, "doesn't support privileged mode")
+
+	b, err := os.ReadFile(report)
+	assert.NilError(t, err)
+	var reports []api.BuildReport
+	assert.NilError(t, json.Unmarshal(b, &reports))
+	assert.Equal(t, len(reports), 1)
+	assert.Equal(t, reports[0].Service, "api")
+	assert.Assert(t, reports[0].Failed)
+	assert.ErrorContains(t, errors.New(reports[0].Error), "doesn't support privileged mode")
+}
@@ -0,0 +1,169 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/buildx/build"
+	"github.com/docker/cli/cli/config/configfile"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+func buildWithContexts(contexts types.Mapping) *types.BuildConfig {
+	return &types.BuildConfig{Context: ".", AdditionalContexts: contexts}
+}
+
+func TestBuildContextService(t *testing.T) {
+	name, ok := buildContextService("service:base")
+	assert.Check(t, ok)
+	assert.Equal(t, name, "base")
+
+	// a directory named after a service is not a reference to it
+	_, ok = buildContextService(filepath.Join(t.TempDir(), "service:base"))
+	assert.Check(t, !ok)
+
+	_, ok = buildContextService("docker-image://alpine")
+	assert.Check(t, !ok)
+}
+
+func TestWithBuildDependencies(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"base": {Name: "base", Build: buildWithContexts(nil)},
+			"app": {Name: "app", Build: buildWithContexts(types.Mapping{
+				"base": "service:base",
+				"src":  "/src",
+			})},
+		},
+	}
+	buildProject, err := withBuildDependencies(project)
+	assert.NilError(t, err)
+	assert.Check(t, buildProject.Services["app"].DependsOn["base"].Required)
+	assert.Check(t, project.Services["app"].DependsOn == nil)
+
+	contexts := toBuildContexts(project, project.Services["app"].Build.AdditionalContexts)
+	assert.Equal(t, contexts["base"].Path, "docker-image://test-base")
+	assert.Equal(t, contexts["src"].Path, "/src")
+}
+
+func TestWithBuildDependenciesErrors(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"a": {Name: "a", Build: buildWithContexts(types.Mapping{"b": "service:b"})},
+			"b": {Name: "b", Build: buildWithContexts(types.Mapping{"a": "service:a"})},
+		},
+	}
+	_, err := withBuildDependencies(project)
+	assert.ErrorContains(t, err, "cycle found")
+
+	project = &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"a": {Name: "a", Build: buildWithContexts(types.Mapping{"b": "service:b"})},
+		},
+	}
+	_, err = withBuildDependencies(project)
+	assert.ErrorContains(t, err, `service "a" build refers to undefined service "b"`)
+}
+
+func TestAddReferencedBuilds(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"base":   {Name: "base", Build: buildWithContexts(nil)},
+			"tools":  {Name: "tools", Build: buildWithContexts(types.Mapping{"base": "service:base"})},
+			"app":    {Name: "app", Build: buildWithContexts(types.Mapping{"tools": "service:tools"})},
+			"cached": {Name: "cached", Build: buildWithContexts(nil)},
+			"other":  {Name: "other", Build: buildWithContexts(types.Mapping{"cached": "service:cached"})},
+		},
+	}
+	toBuild := map[string]serviceToBuild{
+		"app":   {name: "app", service: project.Services["app"]},
+		"other": {name: "other", service: project.Services["other"]},
+	}
+	addReferencedBuilds(project, toBuild, map[string]string{"test-cached": "sha256:1234"})
+	assert.DeepEqual(t, sortedKeys(toBuild), []string{"app", "base", "other", "tools"})
+}
+
+func TestCheckClassicServiceContexts(t *testing.T) {
+	toBuild := map[string]serviceToBuild{
+		"base": {name: "base", service: types.ServiceConfig{Name: "base", Build: buildWithContexts(nil)}},
+		"app":  {name: "app", service: types.ServiceConfig{Name: "app", Build: buildWithContexts(types.Mapping{"base": "service:base"})}},
+	}
+	err := checkClassicServiceContexts(toBuild)
+	assert.ErrorContains(t, err, `service "app" uses service "base" as a build context, which the classic builder doesn't support`)
+
+	delete(toBuild, "app")
+	assert.NilError(t, checkClassicServiceContexts(toBuild))
+}
+
+func TestServiceContextTargets(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"base":  {Name: "base", Build: buildWithContexts(nil)},
+			"tools": {Name: "tools", Build: buildWithContexts(types.Mapping{"base": "service:base"})},
+			"app": {Name: "app", Build: buildWithContexts(types.Mapping{
+				"tools": "service:tools",
+				"src":   "/src",
+			})},
+		},
+	}
+	mockCtrl := gomock.NewController(t)
+	apiClient, cli := prepareMocks(mockCtrl)
+	apiClient.EXPECT().DaemonHost().Return("unix:///var/run/docker.sock").AnyTimes()
+	cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+	s := &composeService{dockerCli: cli}
+	opts, err := s.toBuildOptions(project, project.Services["app"], api.BuildOptions{})
+	assert.NilError(t, err)
+	targets := map[string]build.Options{}
+	assert.NilError(t, s.serviceContextTargets(project, project.Services["app"], opts, api.BuildOptions{}, targets))
+
+	assert.Equal(t, opts.Inputs.NamedContexts["tools"].Path, "target:tools")
+	assert.Equal(t, opts.Inputs.NamedContexts["src"].Path, "/src")
+	// services used as build context are built along, without exporting their image again
+	assert.DeepEqual(t, sortedTargets(targets), []string{"base", "tools"})
+	assert.Equal(t, targets["tools"].Inputs.NamedContexts["base"].Path, "target:base")
+	assert.Check(t, targets["tools"].Exports == nil)
+}
+
+func sortedTargets(m map[string]build.Options) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeys(m map[string]serviceToBuild) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,451 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/command"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+)
+
+const (
+	// bundleModelFile is the path of the resolved Compose model within a bundle
+	bundleModelFile = "compose.yaml"
+	// bundleImagesFile is the path of the list of the bundle images within a bundle
+	bundleImagesFile = "compose-images.json"
+)
+
+// bundleImage is an image included in a bundle, used to verify it once loaded
+type bundleImage struct {
+	Services []string `json:"services"`
+	Image    string   `json:"image"`
+	ID       string   `json:"id"`
+}
+
+func (s *composeService) Save(ctx context.Context, project *types.Project, options api.SaveOptions) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return s.save(ctx, project, options)
+	}, s.stdinfo(), "Saving")
+}
+
+func (s *composeService) save(ctx context.Context, project *types.Project, options api.SaveOptions) error {
+	if options.Output == "" && s.dockerCli.Out().IsTerminal() {
+		return fmt.Errorf("output option is required when saving to terminal")
+	}
+	if err := command.ValidateOutputPath(options.Output); err != nil {
+		return fmt.Errorf("failed to save project: %w", err)
+	}
+
+	w := progress.ContextWriter(ctx)
+	if len(options.Platforms) > 0 {
+		if err := s.pullBundlePlatforms(ctx, project, options.Platforms); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ensureImagesExists(ctx, project, options.Build, api.PullOptions{}); err != nil {
+		return err
+	}
+
+	images, err := s.bundleImages(ctx, project)
+	if err != nil {
+		return err
+	}
+	model, err := project.MarshalYAML()
+	if err != nil {
+		return err
+	}
+	index, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, img := range images {
+		names = append(names, img.Image)
+	}
+	eventName := fmt.Sprintf("save %d images to %s", len(names), options.Output)
+	if options.Output == "" {
+		eventName = fmt.Sprintf("save %d images", len(names))
+	}
+	w.Event(progress.Event{ID: eventName, Status: progress.Working, StatusText: "Saving"})
+	if s.dryRun {
+		w.Event(progress.Event{ID: eventName, Status: progress.Done, StatusText: "Saved"})
+		return nil
+	}
+
+	stream, err := s.apiClient().ImageSave(ctx, names)
+	if err != nil {
+		return err
+	}
+	defer stream.Close() //nolint:errcheck
+
+	var out io.Writer = s.dockerCli.Out()
+	if options.Output != "" {
+		f, err := os.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		out = f
+	}
+	if err := writeBundle(out, stream, map[string][]byte{
+		bundleModelFile:  model,
+		bundleImagesFile: index,
+	}); err != nil {
+		return err
+	}
+	w.Event(progress.Event{ID: eventName, Status: progress.Done, StatusText: "Saved"})
+	return nil
+}
+
+// pullBundlePlatforms pulls the images of the services without a build section for the platforms to save, without
+// changing the image the local tags refer to
+func (s *composeService) pullBundlePlatforms(ctx context.Context, project *types.Project, platforms []string) error {
+	containerdStore, err := s.usesContainerdImageStore(ctx)
+	if err != nil {
+		return err
+	}
+	if !containerdStore && len(platforms) > 1 {
+		return errors.New("saving several platforms requires the containerd image store")
+	}
+	w := progress.ContextWriter(ctx)
+	for _, service := range project.Services {
+		if service.Image == "" || service.Build != nil {
+			continue
+		}
+		inspect, _, err := s.apiClient().ImageInspectWithRaw(ctx, service.Image)
+		if err != nil && !errdefs.IsNotFound(err) {
+			return err
+		}
+		local := err == nil
+		image := service.Image
+		if local {
+			if !containerdStore {
+				// pulling another platform would replace the image the tag refers to
+				if !matchesPlatform(inspect, platforms[0]) {
+					return fmt.Errorf("image %s of service %s is for %s/%s, saving it for %s requires the containerd image store",
+						service.Image, service.Name, inspect.Os, inspect.Architecture, platforms[0])
+				}
+				continue
+			}
+			// the other platforms of the image the tag refers to are pulled by digest, so the tag is left untouched
+			image, err = repoDigest(service.Image, inspect)
+			if err != nil {
+				return fmt.Errorf("image %s of service %s: %w", service.Image, service.Name, err)
+			}
+		}
+		for _, platform := range platforms {
+			variant := service
+			variant.Image = image
+			variant.Platform = platform
+			variant.Name = fmt.Sprintf("%s (%s)", service.Name, platform)
+			if _, _, err := s.pullServiceImage(ctx, variant, s.configFile(), w, false, "", api.PullRetryPolicy{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// usesContainerdImageStore returns true when the engine stores images with containerd, which keeps every platform
+// variant of an image under the same reference
+func (s *composeService) usesContainerdImageStore(ctx context.Context) (bool, error) {
+	info, err := s.apiClient().Info(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, status := range info.DriverStatus {
+		if status[0] == "driver-type" && status[1] == "io.containerd.snapshotter.v1" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchesPlatform(inspect moby.ImageInspect, platform string) bool {
+	p, err := platforms.Parse(platform)
+	if err != nil {
+		return false
+	}
+	return platforms.Only(p).Match(specs.Platform{OS: inspect.Os, Architecture: inspect.Architecture, Variant: inspect.Variant})
+}
+
+// repoDigest returns the digest reference an image was pulled from
+func repoDigest(image string, inspect moby.ImageInspect) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	for _, d := range inspect.RepoDigests {
+		ref, err := reference.ParseNormalizedNamed(d)
+		if err == nil && ref.Name() == named.Name() {
+			return d, nil
+		}
+	}
+	return "", errors.New("image has no digest in the registry to pull other platforms from")
+}
+
+// bundleImages lists the images used by the project services, with their ID in the engine image store
+func (s *composeService) bundleImages(ctx context.Context, project *types.Project) ([]bundleImage, error) {
+	byImage := map[string]*bundleImage{}
+	for _, service := range project.Services {
+		name := api.GetImageNameOrDefault(service, project.Name)
+		img, ok := byImage[name]
+		if !ok {
+			inspect, _, err := s.apiClient().ImageInspectWithRaw(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("image %s for service %s: %w", name, service.Name, err)
+			}
+			img = &bundleImage{Image: name, ID: inspect.ID}
+			byImage[name] = img
+		}
+		img.Services = append(img.Services, service.Name)
+	}
+	var images []bundleImage
+	for _, img := range byImage {
+		sort.Strings(img.Services)
+		images = append(images, *img)
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Image < images[j].Image
+	})
+	return images, nil
+}
+
+// writeBundle copies the OCI layout archive produced by the engine, and appends the extra files to it
+func writeBundle(out io.Writer, layout io.Reader, files map[string][]byte) error {
+	tw := tar.NewWriter(out)
+	tr := tar.NewReader(layout)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := files[header.Name]; ok {
+			// replaced by the bundle file
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		content := files[name]
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			ModTime:  time.Now(),
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (s *composeService) Load(ctx context.Context, options api.LoadOptions) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return s.load(ctx, options)
+	}, s.stdinfo(), "Loading")
+}
+
+func (s *composeService) load(ctx context.Context, options api.LoadOptions) error {
+	var in io.Reader = s.dockerCli.In()
+	if options.Input != "" {
+		f, err := os.Open(options.Input)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		in = f
+	} else if s.dockerCli.In().IsTerminal() {
+		return fmt.Errorf("input option is required when loading from terminal")
+	}
+
+	w := progress.ContextWriter(ctx)
+	eventName := "load bundle"
+	if options.Input != "" {
+		eventName = fmt.Sprintf("load %s", options.Input)
+	}
+	w.Event(progress.Event{ID: eventName, Status: progress.Working, StatusText: "Loading"})
+
+	files := map[string][]byte{bundleModelFile: nil, bundleImagesFile: nil}
+	if s.dryRun {
+		if err := readBundle(in, io.Discard, files); err != nil {
+			return err
+		}
+	} else if err := s.loadBundle(ctx, in, files); err != nil {
+		return err
+	}
+
+	if files[bundleImagesFile] == nil {
+		return fmt.Errorf("not a compose bundle, %s is missing", bundleImagesFile)
+	}
+	var images []bundleImage
+	if err := json.Unmarshal(files[bundleImagesFile], &images); err != nil {
+		return fmt.Errorf("invalid %s: %w", bundleImagesFile, err)
+	}
+	if !s.dryRun {
+		if err := s.verifyBundleImages(ctx, images); err != nil {
+			return err
+		}
+	}
+
+	if options.Model != "" && files[bundleModelFile] != nil {
+		if err := os.WriteFile(options.Model, files[bundleModelFile], 0o644); err != nil {
+			return err
+		}
+	}
+	w.Event(progress.Event{ID: eventName, Status: progress.Done, StatusText: fmt.Sprintf("Loaded %d images", len(images))})
+	return nil
+}
+
+// errBundleConsumed is set on the bundle pipe once the engine stopped reading it
+var errBundleConsumed = errors.New("bundle consumed by the engine")
+
+// loadBundle streams a bundle archive to the engine, capturing the content of the requested files. The bundle is
+// verified while it is streamed, and a corrupted bundle fails the load even if the engine accepted its content.
+func (s *composeService) loadBundle(ctx context.Context, in io.Reader, files map[string][]byte) error {
+	pr, pw := io.Pipe()
+	read := make(chan error, 1)
+	go func() {
+		err := readBundle(in, pw, files)
+		_ = pw.CloseWithError(err)
+		read <- err
+	}()
+
+	response, err := s.apiClient().ImageLoad(ctx, pr, true)
+	if err == nil {
+		err = jsonmessage.DisplayJSONMessagesStream(response.Body, io.Discard, 0, false, nil)
+		_ = response.Body.Close()
+	}
+	// the engine may stop reading at the end of the archive, before its trailing padding: unblock readBundle
+	_ = pr.CloseWithError(errBundleConsumed)
+	if readErr := <-read; readErr != nil && !errors.Is(readErr, errBundleConsumed) {
+		return readErr
+	}
+	return err
+}
+
+// readBundle copies a bundle archive to out, verifying the content of the OCI layout blobs matches their digest, and
+// captures the content of the requested files
+func readBundle(in io.Reader, out io.Writer, files map[string][]byte) error {
+	tr := tar.NewReader(in)
+	tw := tar.NewWriter(out)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		var (
+			content  io.Reader = tr
+			verifier digest.Verifier
+			captured bytes.Buffer
+		)
+		if expected, ok := blobDigest(header.Name); ok {
+			verifier = expected.Verifier()
+			content = io.TeeReader(content, verifier)
+		}
+		if _, ok := files[header.Name]; ok {
+			content = io.TeeReader(content, &captured)
+		}
+		if _, err := io.Copy(tw, content); err != nil {
+			return err
+		}
+		if verifier != nil && !verifier.Verified() {
+			return fmt.Errorf("bundle is corrupted, %s doesn't match its digest", header.Name)
+		}
+		if _, ok := files[header.Name]; ok {
+			files[header.Name] = captured.Bytes()
+		}
+	}
+	return tw.Close()
+}
+
+// blobDigest returns the digest of an OCI layout blob from its path in the layout
+func blobDigest(name string) (digest.Digest, bool) {
+	dir, encoded := path.Split(path.Clean(name))
+	algorithm := path.Base(dir)
+	if path.Dir(path.Clean(dir)) != "blobs" {
+		return "", false
+	}
+	d := digest.NewDigestFromEncoded(digest.Algorithm(algorithm), encoded)
+	if d.Validate() != nil {
+		return "", false
+	}
+	return d, true
+}
+
+// verifyBundleImages checks the images loaded from a bundle match the ones which were saved
+func (s *composeService) verifyBundleImages(ctx context.Context, images []bundleImage) error {
+	var errs []error
+	for _, img := range images {
+		inspect, _, err := s.apiClient().ImageInspectWithRaw(ctx, img.Image)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("image %s was not loaded: %w", img.Image, err))
+			continue
+		}
+		if inspect.ID != img.ID {
+			errs = append(errs, fmt.Errorf("image %s digest mismatch: expected %s, loaded %s", img.Image, img.ID, inspect.ID))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,218 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/cli/cli/config/configfile"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/errdefs"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func ociLayout(t *testing.T, blobs ...string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	add := func(name string, content string) {
+		assert.NilError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		assert.NilError(t, err)
+	}
+	add("oci-layout", `{"imageLayoutVersion":"1.0.0"}`)
+	for _, blob := range blobs {
+		add("blobs/sha256/"+digest.FromString(blob).Encoded(), blob)
+	}
+	assert.NilError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	var bundle bytes.Buffer
+	err := writeBundle(&bundle, bytes.NewReader(ociLayout(t, "layer", "config")), map[string][]byte{
+		bundleModelFile:  []byte("name: test\n"),
+		bundleImagesFile: []byte(`[]`),
+	})
+	assert.NilError(t, err)
+
+	var layout bytes.Buffer
+	files := map[string][]byte{bundleModelFile: nil, bundleImagesFile: nil}
+	assert.NilError(t, readBundle(&bundle, &layout, files))
+	assert.Equal(t, string(files[bundleModelFile]), "name: test\n")
+	assert.Equal(t, string(files[bundleImagesFile]), "[]")
+
+	var names []string
+	tr := tar.NewReader(&layout)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NilError(t, err)
+		names = append(names, header.Name)
+	}
+	assert.DeepEqual(t, names, []string{
+		"oci-layout",
+		"blobs/sha256/" + digest.FromString("layer").Encoded(),
+		"blobs/sha256/" + digest.FromString("config").Encoded(),
+		bundleImagesFile,
+		bundleModelFile,
+	})
+}
+
+func TestReadBundleCorrupted(t *testing.T) {
+	layout := ociLayout(t, "layer")
+	corrupted := bytes.Replace(layout, []byte("layer"), []byte("LAYER"), 1)
+	err := readBundle(bytes.NewReader(corrupted), io.Discard, map[string][]byte{})
+	assert.ErrorContains(t, err, "doesn't match its digest")
+}
+
+func TestBlobDigest(t *testing.T) {
+	d := digest.FromString("layer")
+	found, ok := blobDigest("blobs/sha256/" + d.Encoded())
+	assert.Check(t, ok)
+	assert.Equal(t, found, d)
+
+	_, ok = blobDigest("index.json")
+	assert.Check(t, !ok)
+	_, ok = blobDigest("blobs/sha256/nope")
+	assert.Check(t, !ok)
+}
+
+func TestVerifyBundleImages(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "nginx:1.25").Return(moby.ImageInspect{ID: "sha256:1234"}, nil, nil)
+	apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "test-app").Return(moby.ImageInspect{ID: "sha256:abcd"}, nil, nil)
+
+	err := tested.verifyBundleImages(context.Background(), []bundleImage{
+		{Services: []string{"web"}, Image: "nginx:1.25", ID: "sha256:1234"},
+		{Services: []string{"app"}, Image: "test-app", ID: "sha256:5678"},
+	})
+	assert.Error(t, err, "image test-app digest mismatch: expected sha256:5678, loaded sha256:abcd")
+}
+
+func TestLoadBundle(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	var bundle bytes.Buffer
+	err := writeBundle(&bundle, bytes.NewReader(ociLayout(t, "layer")), map[string][]byte{
+		bundleImagesFile: []byte(`[]`),
+	})
+	assert.NilError(t, err)
+	// the engine reads the archive up to its end marker, but not the trailing padding
+	loaded := func(_ context.Context, in io.Reader, _ bool) (image.LoadResponse, error) {
+		tr := tar.NewReader(in)
+		for {
+			if _, err := tr.Next(); err != nil {
+				break
+			}
+		}
+		return image.LoadResponse{Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+
+	apiClient.EXPECT().ImageLoad(gomock.Any(), gomock.Any(), true).DoAndReturn(loaded)
+	files := map[string][]byte{bundleImagesFile: nil}
+	assert.NilError(t, tested.loadBundle(context.Background(), bytes.NewReader(bundle.Bytes()), files))
+	assert.Equal(t, string(files[bundleImagesFile]), "[]")
+
+	// a corrupted blob fails the load, even if the engine accepted the archive
+	corrupted := bytes.Replace(bundle.Bytes(), []byte("layer"), []byte("LAYER"), 1)
+	apiClient.EXPECT().ImageLoad(gomock.Any(), gomock.Any(), true).DoAndReturn(loaded)
+	err = tested.loadBundle(context.Background(), bytes.NewReader(corrupted), map[string][]byte{})
+	assert.ErrorContains(t, err, "doesn't match its digest")
+}
+
+func TestPullBundlePlatforms(t *testing.T) {
+	project := &types.Project{Services: types.Services{
+		"db":  {Name: "db", Image: "postgres:16"},
+		"app": {Name: "app", Image: "app", Build: &types.BuildConfig{Context: "."}},
+	}}
+	classicStore := system.Info{DriverStatus: [][2]string{{"Backing Filesystem", "extfs"}}}
+	containerdStore := system.Info{DriverStatus: [][2]string{{"driver-type", "io.containerd.snapshotter.v1"}}}
+	local := moby.ImageInspect{
+		ID:           "sha256:local",
+		Os:           "linux",
+		Architecture: "amd64",
+		RepoDigests:  []string{"postgres@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+	}
+
+	t.Run("classic store can't hold several platforms", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		apiClient, cli := prepareMocks(mockCtrl)
+		tested := composeService{dockerCli: cli}
+		apiClient.EXPECT().Info(gomock.Any()).Return(classicStore, nil)
+		err := tested.pullBundlePlatforms(context.Background(), project, []string{"linux/amd64", "linux/arm64"})
+		assert.ErrorContains(t, err, "requires the containerd image store")
+	})
+
+	t.Run("classic store keeps the local image", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		apiClient, cli := prepareMocks(mockCtrl)
+		tested := composeService{dockerCli: cli}
+		apiClient.EXPECT().Info(gomock.Any()).Return(classicStore, nil).Times(2)
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "postgres:16").Return(local, nil, nil).Times(2)
+		assert.NilError(t, tested.pullBundlePlatforms(context.Background(), project, []string{"linux/amd64"}))
+		err := tested.pullBundlePlatforms(context.Background(), project, []string{"linux/arm64"})
+		assert.ErrorContains(t, err, "is for linux/amd64, saving it for linux/arm64 requires the containerd image store")
+	})
+
+	t.Run("containerd store pulls platforms by digest", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		apiClient, cli := prepareMocks(mockCtrl)
+		cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+		tested := composeService{dockerCli: cli}
+		apiClient.EXPECT().Info(gomock.Any()).Return(containerdStore, nil)
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "postgres:16").Return(local, nil, nil)
+		for _, platform := range []string{"linux/amd64", "linux/arm64"} {
+			apiClient.EXPECT().ImagePull(gomock.Any(), local.RepoDigests[0], gomock.Cond(func(x any) bool {
+				return x.(image.PullOptions).Platform == platform
+			})).Return(io.NopCloser(strings.NewReader("")), nil)
+		}
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), local.RepoDigests[0]).Return(local, nil, nil).Times(2)
+		assert.NilError(t, tested.pullBundlePlatforms(context.Background(), project, []string{"linux/amd64", "linux/arm64"}))
+	})
+
+	t.Run("missing image is pulled by name", func(t *testing.T) {
+		mockCtrl := gomock.NewController(t)
+		apiClient, cli := prepareMocks(mockCtrl)
+		cli.EXPECT().ConfigFile().Return(&configfile.ConfigFile{}).AnyTimes()
+		tested := composeService{dockerCli: cli}
+		apiClient.EXPECT().Info(gomock.Any()).Return(classicStore, nil)
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "postgres:16").Return(moby.ImageInspect{}, nil, errdefs.NotFound(errors.New("no such image")))
+		apiClient.EXPECT().ImagePull(gomock.Any(), "postgres:16", gomock.Any()).Return(io.NopCloser(strings.NewReader("")), nil)
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "postgres:16").Return(local, nil, nil)
+		assert.NilError(t, tested.pullBundlePlatforms(context.Background(), project, []string{"linux/amd64"}))
+	})
+}
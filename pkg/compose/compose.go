@@ -70,6 +70,7 @@ type composeService struct {
 	clock          clockwork.Clock
 	maxConcurrency int
 	dryRun         bool
+	hostCommands   bool
 }
 
 // Close releases any connections/resources held by the underlying clients.
@@ -99,6 +100,11 @@ func (s *composeService) MaxConcurrency(i int) {
 	s.maxConcurrency = i
 }
 
+// AllowHostCommands allows projects to run commands on the host, with x-hooks or a command secret provider
+func (s *composeService) AllowHostCommands(allowed bool) {
+	s.hostCommands = allowed
+}
+
 func (s *composeService) DryRunMode(ctx context.Context, dryRun bool) (context.Context, error) {
 	s.dryRun = dryRun
 	if dryRun {
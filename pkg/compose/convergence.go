@@ -59,6 +59,10 @@ type convergence struct {
 	service       *composeService
 	observedState map[string]Containers
 	stateMutex    sync.Mutex
+	// pulls are the images being pulled in background, services wait for their own image before being converged
+	pulls *imagePulls
+	// start, if set, starts a service as soon as it has been converged
+	start func(ctx context.Context, service types.ServiceConfig) error
 }
 
 func (c *convergence) getObservedState(serviceName string) Containers {
@@ -96,11 +100,25 @@ func (c *convergence) apply(ctx context.Context, project *types.Project, options
 		}
 
 		return tracing.SpanWrapFunc("service/apply", tracing.ServiceOptions(service), func(ctx context.Context) error {
+			if c.pulls != nil {
+				digest, err := c.pulls.wait(ctx, service)
+				if err != nil {
+					return err
+				}
+				if digest != "" {
+					service.CustomLabels = service.CustomLabels.Add(api.ImageDigestLabel, digest)
+				}
+				service = c.pulls.labelMirrored(service)
+			}
 			strategy := options.RecreateDependencies
 			if utils.StringContains(options.Services, name) {
 				strategy = options.Recreate
 			}
-			return c.ensureService(ctx, project, service, strategy, options.Inherit, options.Timeout)
+			err := c.ensureService(ctx, project, service, strategy, options.Inherit, options.Timeout)
+			if err != nil || c.start == nil {
+				return err
+			}
+			return c.start(ctx, service)
 		})(ctx)
 	})
 }
@@ -178,11 +196,11 @@ func (c *convergence) ensureService(ctx context.Context, project *types.Project,
 		name := getContainerProgressName(container)
 		switch container.State {
 		case ContainerRunning:
-			w.Event(progress.RunningEvent(name))
+			w.Event(progress.RunningEvent(name).WithKind(api.ProgressKindContainerState))
 		case ContainerCreated:
 		case ContainerRestarting:
 		case ContainerExited:
-			w.Event(progress.CreatedEvent(name))
+			w.Event(progress.CreatedEvent(name).WithKind(api.ProgressKindContainerState))
 		default:
 			container := container
 			eg.Go(tracing.EventWrapFuncForErrGroup(ctx, "service/start", tracing.ContainerOptions(container), func(ctx context.Context) error {
@@ -347,7 +365,7 @@ func getContainerProgressName(container moby.Container) string {
 func containerEvents(containers Containers, eventFunc func(string) progress.Event) []progress.Event {
 	events := []progress.Event{}
 	for _, container := range containers {
-		events = append(events, eventFunc(getContainerProgressName(container)))
+		events = append(events, eventFunc(getContainerProgressName(container)).WithKind(api.ProgressKindContainerState))
 	}
 	return events
 }
@@ -355,7 +373,7 @@ func containerEvents(containers Containers, eventFunc func(string) progress.Even
 func containerReasonEvents(containers Containers, eventFunc func(string, string) progress.Event, reason string) []progress.Event {
 	events := []progress.Event{}
 	for _, container := range containers {
-		events = append(events, eventFunc(getContainerProgressName(container), reason))
+		events = append(events, eventFunc(getContainerProgressName(container), reason).WithKind(api.ProgressKindContainerState))
 	}
 	return events
 }
@@ -372,6 +390,11 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 	}
 	eg, _ := errgroup.WithContext(ctx)
 	w := progress.ContextWriter(ctx)
+	var (
+		slowestMtx  sync.Mutex
+		slowest     string
+		slowestWait time.Duration
+	)
 	for dep, config := range dependencies {
 		if shouldWait, err := shouldWaitForDependency(dep, config, project); err != nil {
 			return err
@@ -390,10 +413,18 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 		}
 
 		dep, config := dep, config
-		eg.Go(func() error {
+		eg.Go(tracing.SpanWrapFuncForErrGroup(ctx, "dependency/wait", tracing.DependencyOptions(dependant, dep, config), func(ctx context.Context) error {
+			start := time.Now()
+			defer func() {
+				slowestMtx.Lock()
+				defer slowestMtx.Unlock()
+				if waited := time.Since(start); waited > slowestWait {
+					slowest, slowestWait = dep, waited
+				}
+			}()
 			ticker := time.NewTicker(500 * time.Millisecond)
 			defer ticker.Stop()
-			for {
+			for probe := 1; ; probe++ {
 				select {
 				case <-ticker.C:
 				case <-ctx.Done():
@@ -402,6 +433,7 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 				switch config.Condition {
 				case ServiceConditionRunningOrHealthy:
 					healthy, err := s.isServiceHealthy(ctx, waitingFor, true)
+					tracing.AddEventToSpan(ctx, "dependency/probe", probeAttributes(probe, healthy, err)...)
 					if err != nil {
 						if !config.Required {
 							w.Events(containerReasonEvents(waitingFor, progress.SkippedEvent, fmt.Sprintf("optional dependency %q is not running or is unhealthy", dep)))
@@ -412,10 +444,12 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 					}
 					if healthy {
 						w.Events(containerEvents(waitingFor, progress.Healthy))
+						recordHealthy(ctx, dep)
 						return nil
 					}
 				case types.ServiceConditionHealthy:
 					healthy, err := s.isServiceHealthy(ctx, waitingFor, false)
+					tracing.AddEventToSpan(ctx, "dependency/probe", probeAttributes(probe, healthy, err)...)
 					if err != nil {
 						if !config.Required {
 							w.Events(containerReasonEvents(waitingFor, progress.SkippedEvent, fmt.Sprintf("optional dependency %q failed to start", dep)))
@@ -427,16 +461,19 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 					}
 					if healthy {
 						w.Events(containerEvents(waitingFor, progress.Healthy))
+						recordHealthy(ctx, dep)
 						return nil
 					}
 				case types.ServiceConditionCompletedSuccessfully:
 					exited, code, err := s.isServiceCompleted(ctx, waitingFor)
+					tracing.AddEventToSpan(ctx, "dependency/probe", probeAttributes(probe, exited, err)...)
 					if err != nil {
 						return err
 					}
 					if exited {
 						if code == 0 {
 							w.Events(containerEvents(waitingFor, progress.Exited))
+							recordHealthy(ctx, dep)
 							return nil
 						}
 
@@ -457,15 +494,32 @@ func (s *composeService) waitDependencies(ctx context.Context, project *types.Pr
 					return nil
 				}
 			}
-		})
+		}))
 	}
 	err := eg.Wait()
+	if slowest != "" {
+		tracing.AddAttributeToSpan(ctx,
+			attribute.String("dependency.slowest", slowest),
+			attribute.Float64("dependency.slowest_wait", slowestWait.Seconds()))
+	}
 	if errors.Is(err, context.DeadlineExceeded) {
 		return fmt.Errorf("timeout waiting for dependencies")
 	}
 	return err
 }
 
+// probeAttributes describes an iteration of polling the state of a dependency, ready once it is healthy or completed
+func probeAttributes(probe int, ready bool, err error) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int("probe.iteration", probe),
+		attribute.Bool("probe.ready", ready),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("exception.message", err.Error()))
+	}
+	return attrs
+}
+
 func shouldWaitForDependency(serviceName string, dependencyConfig types.ServiceDependency, project *types.Project) (bool, error) {
 	if dependencyConfig.Condition == types.ServiceConditionStarted {
 		// already managed by InDependencyOrder
@@ -510,12 +564,12 @@ func (s *composeService) createContainer(ctx context.Context, project *types.Pro
 	name string, number int, opts createOptions) (container moby.Container, err error) {
 	w := progress.ContextWriter(ctx)
 	eventName := "Container " + name
-	w.Event(progress.CreatingEvent(eventName))
+	w.Event(progress.CreatingEvent(eventName).WithKind(api.ProgressKindContainerState))
 	container, err = s.createMobyContainer(ctx, project, service, name, number, nil, opts, w)
 	if err != nil {
 		return
 	}
-	w.Event(progress.CreatedEvent(eventName))
+	w.Event(progress.CreatedEvent(eventName).WithKind(api.ProgressKindContainerState))
 	return
 }
 
@@ -523,7 +577,7 @@ func (s *composeService) recreateContainer(ctx context.Context, project *types.P
 	replaced moby.Container, inherit bool, timeout *time.Duration) (moby.Container, error) {
 	var created moby.Container
 	w := progress.ContextWriter(ctx)
-	w.Event(progress.NewEvent(getContainerProgressName(replaced), progress.Working, "Recreate"))
+	w.Event(progress.NewEvent(getContainerProgressName(replaced), progress.Working, "Recreate").WithKind(api.ProgressKindContainerState))
 
 	number, err := strconv.Atoi(replaced.Labels[api.ContainerNumberLabel])
 	if err != nil {
@@ -563,18 +617,187 @@ func (s *composeService) recreateContainer(ctx context.Context, project *types.P
 		return created, err
 	}
 
-	w.Event(progress.NewEvent(getContainerProgressName(replaced), progress.Done, "Recreated"))
+	w.Event(progress.NewEvent(getContainerProgressName(replaced), progress.Done, "Recreated").WithKind(api.ProgressKindContainerState))
 	return created, err
 }
 
+// defaultStartFirstMonitor is how long a start-first replacement container is given to become
+// healthy when the service doesn't set deploy.update_config.monitor
+const defaultStartFirstMonitor = 60 * time.Second
+
+// isStartFirst returns true when the service asks to start the replacement container before
+// stopping the one it replaces
+func isStartFirst(service types.ServiceConfig) bool {
+	return service.Deploy != nil && service.Deploy.UpdateConfig != nil &&
+		service.Deploy.UpdateConfig.Order == "start-first"
+}
+
+// canStartFirst checks the replacement container can run side by side with the replaced one.
+// Fixed host ports, static addresses and host networking can only be held by one container at a time.
+// A healthcheck, set by the service or its image, is required to tell when the replacement is ready,
+// as a running container may not yet serve anything.
+func (s *composeService) canStartFirst(ctx context.Context, project *types.Project, service types.ServiceConfig) error {
+	for _, port := range service.Ports {
+		if port.Published != "" {
+			return fmt.Errorf("service %q publishes host port %s", service.Name, port.Published)
+		}
+	}
+	for name, cfg := range service.Networks {
+		if cfg != nil && (cfg.Ipv4Address != "" || cfg.Ipv6Address != "" || cfg.MacAddress != "") {
+			return fmt.Errorf("service %q uses a static address on network %s", service.Name, name)
+		}
+	}
+	if service.NetworkMode == "host" {
+		return fmt.Errorf("service %q uses host networking", service.Name)
+	}
+	noHealthcheck := fmt.Errorf("service %q has no healthcheck to tell when the replacement container is ready", service.Name)
+	if service.HealthCheck != nil {
+		if service.HealthCheck.Disable {
+			return noHealthcheck
+		}
+		return nil
+	}
+	image, _, err := s.apiClient().ImageInspectWithRaw(ctx, containerImage(project, service))
+	if err != nil {
+		return err
+	}
+	if image.Config == nil || image.Config.Healthcheck == nil || len(image.Config.Healthcheck.Test) == 0 ||
+		image.Config.Healthcheck.Test[0] == "NONE" {
+		return noHealthcheck
+	}
+	return nil
+}
+
+// recreateContainerStartFirst replaces a running container without downtime: the new container
+// is created under a temporary name and without the service network aliases, started with its
+// post_start hooks, and only once it is healthy does it take over the aliases and the name of the
+// replaced container.
+// If the new container fails to become healthy it is removed and the replaced one keeps running.
+func (s *composeService) recreateContainerStartFirst(ctx context.Context, project *types.Project, service types.ServiceConfig,
+	replaced moby.Container, timeout *time.Duration) (moby.Container, error) {
+	var created moby.Container
+	w := progress.ContextWriter(ctx)
+	eventName := getContainerProgressName(replaced)
+	w.Event(progress.NewEvent(eventName, progress.Working, "Recreate").WithKind(api.ProgressKindContainerState))
+
+	number, err := strconv.Atoi(replaced.Labels[api.ContainerNumberLabel])
+	if err != nil {
+		return created, err
+	}
+
+	name := getContainerName(project.Name, service, number)
+	tmpName := fmt.Sprintf("%s_%s", replaced.ID[:12], name)
+	opts := createOptions{
+		AutoRemove:        false,
+		AttachStdin:       false,
+		UseNetworkAliases: false,
+		Labels:            mergeLabels(service.Labels, service.CustomLabels).Add(api.ContainerReplaceLabel, replaced.ID),
+	}
+	created, err = s.createMobyContainer(ctx, project, service, tmpName, number, &replaced, opts, w)
+	if err != nil {
+		return created, err
+	}
+
+	discard := func(cause error) (moby.Container, error) {
+		w.Event(progress.ErrorMessageEvent(eventName, cause.Error()).WithKind(api.ProgressKindContainerState))
+		err := s.apiClient().ContainerRemove(ctx, created.ID, containerType.RemoveOptions{Force: true})
+		if err != nil {
+			logrus.Warnf("failed to remove replacement container %s: %v", tmpName, err)
+		}
+		return replaced, cause
+	}
+
+	err = s.apiClient().ContainerStart(ctx, created.ID, containerType.StartOptions{})
+	if err != nil {
+		return discard(err)
+	}
+	for _, hook := range service.PostStart {
+		err = s.runHook(ctx, created, service, hook, "post_start", nil)
+		if err != nil {
+			return discard(err)
+		}
+	}
+
+	monitor := defaultStartFirstMonitor
+	if service.Deploy.UpdateConfig.Monitor > 0 {
+		monitor = time.Duration(service.Deploy.UpdateConfig.Monitor)
+	}
+	err = s.waitHealthy(ctx, created, monitor)
+	if err != nil {
+		return discard(fmt.Errorf("replacement container for %s did not become healthy, keeping the current one: %w", name, err))
+	}
+
+	// swap network aliases so that the service name resolves to the new container
+	links, err := s.getLinks(ctx, project.Name, service, number)
+	if err != nil {
+		return discard(err)
+	}
+	for _, networkKey := range service.NetworksByPriority() {
+		mobyNetworkName := project.Networks[networkKey].Name
+		err = s.apiClient().NetworkDisconnect(ctx, mobyNetworkName, created.ID, false)
+		if err != nil {
+			return discard(err)
+		}
+		epSettings := createEndpointSettings(project, service, number, networkKey, links, true)
+		err = s.apiClient().NetworkConnect(ctx, mobyNetworkName, created.ID, epSettings)
+		if err != nil {
+			return discard(err)
+		}
+	}
+
+	timeoutInSecond := utils.DurationSecondToInt(timeout)
+	err = s.apiClient().ContainerStop(ctx, replaced.ID, containerType.StopOptions{Timeout: timeoutInSecond})
+	if err != nil {
+		return created, err
+	}
+
+	err = s.apiClient().ContainerRemove(ctx, replaced.ID, containerType.RemoveOptions{})
+	if err != nil {
+		return created, err
+	}
+
+	err = s.apiClient().ContainerRename(ctx, created.ID, name)
+	if err != nil {
+		return created, err
+	}
+
+	w.Event(progress.NewEvent(eventName, progress.Done, "Recreated").WithKind(api.ProgressKindContainerState))
+	return created, nil
+}
+
+// waitHealthy polls a container until it is healthy, failing if it has no healthcheck
+func (s *composeService) waitHealthy(ctx context.Context, container moby.Container, timeout time.Duration) error {
+	return tracing.SpanWrapFunc("container/wait_healthy", tracing.ContainerOptions(container), func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for probe := 1; ; probe++ {
+			healthy, err := s.isServiceHealthy(ctx, Containers{container}, false)
+			tracing.AddEventToSpan(ctx, "container/probe", probeAttributes(probe, healthy, err)...)
+			if err != nil {
+				return err
+			}
+			if healthy {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("timeout after %s", timeout)
+			case <-ticker.C:
+			}
+		}
+	})(ctx)
+}
+
 func (s *composeService) startContainer(ctx context.Context, container moby.Container) error {
 	w := progress.ContextWriter(ctx)
-	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Working, "Restart"))
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Working, "Restart").WithKind(api.ProgressKindContainerState))
 	err := s.apiClient().ContainerStart(ctx, container.ID, containerType.StartOptions{})
 	if err != nil {
 		return err
 	}
-	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Done, "Restarted"))
+	w.Event(progress.NewEvent(getContainerProgressName(container), progress.Done, "Restarted").WithKind(api.ProgressKindContainerState))
 	return nil
 }
 
@@ -586,6 +809,29 @@ func (s *composeService) createMobyContainer(ctx context.Context,
 	inherit *moby.Container,
 	opts createOptions,
 	w progress.Writer,
+) (moby.Container, error) {
+	defer recordTiming(ctx, service.Name, timingCreate, time.Now())
+	var created moby.Container
+	spanOpts := tracing.SpanOptions{trace.WithAttributes(
+		attribute.String("service.name", service.Name),
+		attribute.String("container.name", name),
+	)}
+	err := tracing.SpanWrapFunc("container/create", spanOpts, func(ctx context.Context) error {
+		var err error
+		created, err = s.doCreateMobyContainer(ctx, project, service, name, number, inherit, opts, w)
+		return err
+	})(ctx)
+	return created, err
+}
+
+func (s *composeService) doCreateMobyContainer(ctx context.Context,
+	project *types.Project,
+	service types.ServiceConfig,
+	name string,
+	number int,
+	inherit *moby.Container,
+	opts createOptions,
+	w progress.Writer,
 ) (moby.Container, error) {
 	var created moby.Container
 	cfgs, err := s.getCreateConfigs(ctx, project, service, number, inherit, opts)
@@ -777,10 +1023,14 @@ func (s *composeService) startService(ctx context.Context,
 		return nil
 	}
 
+	waiting := time.Now()
 	err := s.waitDependencies(ctx, project, service.Name, service.DependsOn, containers, timeout)
 	if err != nil {
 		return err
 	}
+	if len(service.DependsOn) > 0 {
+		recordTiming(ctx, service.Name, timingWait, waiting)
+	}
 
 	if len(containers) == 0 {
 		if service.GetScale() == 0 {
@@ -790,25 +1040,38 @@ func (s *composeService) startService(ctx context.Context,
 	}
 
 	w := progress.ContextWriter(ctx)
+	var started bool
 	for _, container := range containers.filter(isService(service.Name)) {
 		if container.State == ContainerRunning {
 			continue
 		}
+		started = true
 		eventName := getContainerProgressName(container)
-		w.Event(progress.StartingEvent(eventName))
-		err = s.apiClient().ContainerStart(ctx, container.ID, containerType.StartOptions{})
-		if err != nil {
-			return err
-		}
-
-		for _, hook := range service.PostStart {
-			err = s.runHook(ctx, container, service, hook, listener)
+		w.Event(progress.StartingEvent(eventName).WithKind(api.ProgressKindContainerState))
+		err = tracing.SpanWrapFunc("container/start", tracing.ContainerOptions(container), func(ctx context.Context) error {
+			starting := time.Now()
+			err := s.apiClient().ContainerStart(ctx, container.ID, containerType.StartOptions{})
 			if err != nil {
 				return err
 			}
+			recordTiming(ctx, service.Name, timingStart, starting)
+
+			for _, hook := range service.PostStart {
+				err = s.runHook(ctx, container, service, hook, "post_start", listener)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})(ctx)
+		if err != nil {
+			return err
 		}
 
-		w.Event(progress.StartedEvent(eventName))
+		w.Event(progress.StartedEvent(eventName).WithKind(api.ProgressKindContainerState))
+	}
+	if started {
+		s.watchHealthy(ctx, service.Name, containers.filter(isService(service.Name)))
 	}
 	return nil
 }
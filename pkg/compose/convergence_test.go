@@ -29,6 +29,10 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	"github.com/docker/go-connections/nat"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 
@@ -254,6 +258,60 @@ func TestWaitDependencies(t *testing.T) {
 		}
 		assert.NilError(t, tested.waitDependencies(context.Background(), &project, "", dependencies, nil, 0))
 	})
+	t.Run("should trace the dependency waited on and its probes", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(provider)
+		defer otel.SetTracerProvider(previous)
+
+		project := types.Project{Name: strings.ToLower(testProject), Services: types.Services{
+			"db": {Name: "db", Scale: intPtr(1)},
+		}}
+		dependencies := types.DependsOnConfig{
+			"db": {Condition: types.ServiceConditionHealthy, Required: true},
+		}
+		inspect := func(status string) moby.ContainerJSON {
+			return moby.ContainerJSON{
+				ContainerJSONBase: &moby.ContainerJSONBase{
+					Name:  "/db",
+					State: &moby.ContainerState{Status: ContainerRunning, Health: &moby.Health{Status: status}},
+				},
+				Config: &containerType.Config{Healthcheck: &containerType.HealthConfig{Test: []string{"CMD", "true"}}},
+			}
+		}
+		gomock.InOrder(
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "db-id").Return(inspect(moby.Starting), nil),
+			apiClient.EXPECT().ContainerInspect(gomock.Any(), "db-id").Return(inspect(moby.Healthy), nil),
+		)
+		containers := Containers{testContainer("db", "db-id", false)}
+
+		ctx, parent := provider.Tracer("").Start(context.Background(), "service/apply")
+		assert.NilError(t, tested.waitDependencies(ctx, &project, "web", dependencies, containers, 0))
+		parent.End()
+
+		spans := recorder.Ended()
+		assert.Equal(t, len(spans), 2)
+		wait := spans[0]
+		assert.Equal(t, wait.Name(), "dependency/wait")
+		assert.Equal(t, wait.Parent().SpanID(), parent.SpanContext().SpanID())
+		assert.Equal(t, spanAttribute(wait.Attributes(), "dependency.name").AsString(), "db")
+		assert.Equal(t, spanAttribute(wait.Attributes(), "dependency.dependant").AsString(), "web")
+
+		events := wait.Events()
+		assert.Equal(t, len(events), 2)
+		assert.Check(t, !spanAttribute(events[0].Attributes, "probe.ready").AsBool())
+		assert.Check(t, spanAttribute(events[1].Attributes, "probe.ready").AsBool())
+
+		assert.Equal(t, spanAttribute(spans[1].Attributes(), "dependency.slowest").AsString(), "db")
+		assert.Check(t, spanAttribute(spans[1].Attributes(), "dependency.slowest_wait").AsFloat64() > 0)
+	})
+}
+
+func spanAttribute(attrs []attribute.KeyValue, key string) attribute.Value {
+	set := attribute.NewSet(attrs...)
+	value, _ := set.Value(attribute.Key(key))
+	return value
 }
 
 func TestCreateMobyContainer(t *testing.T) {
@@ -434,3 +492,52 @@ func TestCreateMobyContainer(t *testing.T) {
 	})
 
 }
+
+func TestCanStartFirst(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+	project := &types.Project{Name: "test"}
+	ctx := context.Background()
+
+	service := types.ServiceConfig{
+		Name:  "web",
+		Image: "web:latest",
+		Deploy: &types.DeployConfig{
+			UpdateConfig: &types.UpdateConfig{Order: "start-first"},
+		},
+		Ports: []types.ServicePortConfig{{Target: 80}},
+	}
+	assert.Assert(t, isStartFirst(service))
+	imageHealthcheck := func(test ...string) moby.ImageInspect {
+		return moby.ImageInspect{Config: &containerType.Config{Healthcheck: &containerType.HealthConfig{Test: test}}}
+	}
+	gomock.InOrder(
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "web:latest").Return(moby.ImageInspect{Config: &containerType.Config{}}, nil, nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "web:latest").Return(imageHealthcheck("NONE"), nil, nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "web:latest").Return(imageHealthcheck("CMD", "true"), nil, nil),
+	)
+	assert.ErrorContains(t, tested.canStartFirst(ctx, project, service), "no healthcheck")
+	assert.ErrorContains(t, tested.canStartFirst(ctx, project, service), "no healthcheck")
+	// the image ships its own healthcheck
+	assert.NilError(t, tested.canStartFirst(ctx, project, service))
+
+	service.HealthCheck = &types.HealthCheckConfig{Disable: true}
+	assert.ErrorContains(t, tested.canStartFirst(ctx, project, service), "no healthcheck")
+
+	service.HealthCheck = &types.HealthCheckConfig{Test: types.HealthCheckTest{"CMD", "true"}}
+	assert.NilError(t, tested.canStartFirst(ctx, project, service))
+
+	service.Ports = []types.ServicePortConfig{{Target: 80, Published: "8080"}}
+	assert.ErrorContains(t, tested.canStartFirst(ctx, project, service), "publishes host port 8080")
+
+	service.Ports = nil
+	service.Networks = map[string]*types.ServiceNetworkConfig{
+		"default": {Ipv4Address: "10.0.0.2"},
+	}
+	assert.ErrorContains(t, tested.canStartFirst(ctx, project, service), "static address")
+
+	service.Deploy = nil
+	assert.Assert(t, !isStartFirst(service))
+}
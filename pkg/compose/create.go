@@ -71,11 +71,19 @@ func (s *composeService) Create(ctx context.Context, project *types.Project, cre
 }
 
 func (s *composeService) create(ctx context.Context, project *types.Project, options api.CreateOptions) error {
+	return s.createAndStart(ctx, project, options, nil)
+}
+
+// createAndStart creates the project services. When start is set, images for services which can't be built are
+// pulled in background, and each service is created then started as soon as its own image and its dependencies are
+// ready, without waiting for all images to be pulled.
+func (s *composeService) createAndStart(ctx context.Context, project *types.Project, options api.CreateOptions,
+	start func(ctx context.Context, service types.ServiceConfig) error) (err error) {
 	if len(options.Services) == 0 {
 		options.Services = project.ServiceNames()
 	}
 
-	err := project.CheckContainerNameUnicity()
+	err = project.CheckContainerNameUnicity()
 	if err != nil {
 		return err
 	}
@@ -86,10 +94,24 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 		return err
 	}
 
-	err = s.ensureImagesExists(ctx, project, options.Build, options.QuietPull)
+	pulls, err := s.ensureImages(ctx, project, options.Build, api.PullOptions{
+		Quiet:       options.QuietPull,
+		Retry:       options.PullRetry,
+		Concurrency: options.PullConcurrency,
+	}, start != nil)
 	if err != nil {
 		return err
 	}
+	if pulls != nil {
+		defer func() {
+			if err != nil {
+				// don't leave pulls running in background once up failed
+				pulls.stop()
+				return
+			}
+			err = pulls.waitAll()
+		}()
+	}
 
 	prepareNetworks(project)
 
@@ -115,7 +137,10 @@ func (s *composeService) create(ctx context.Context, project *types.Project, opt
 				"--remove-orphans flag to clean it up.", orphans.names())
 		}
 	}
-	return newConvergence(options.Services, observedState, s).apply(ctx, project, options)
+	c := newConvergence(options.Services, observedState, s)
+	c.pulls = pulls
+	c.start = start
+	return c.apply(ctx, project, options)
 }
 
 func prepareNetworks(project *types.Project) {
@@ -264,7 +289,7 @@ func (s *composeService) getCreateConfigs(ctx context.Context,
 		AttachStderr:    true,
 		AttachStdout:    true,
 		Cmd:             runCmd,
-		Image:           api.GetImageNameOrDefault(service, p.Name),
+		Image:           containerImage(p, service),
 		WorkingDir:      service.WorkingDir,
 		Entrypoint:      entrypoint,
 		NetworkDisabled: service.NetworkMode == "disabled",
@@ -518,6 +543,15 @@ func (s *composeService) prepareLabels(labels types.Labels, service types.Servic
 	return labels, nil
 }
 
+// containerImage returns the image reference the containers of a service are created from
+func containerImage(project *types.Project, service types.ServiceConfig) string {
+	if mirror, ok := service.CustomLabels[api.ImageMirrorLabel]; ok {
+		// a digest can't be tagged, an image pinned by digest pulled from a mirror is only known by the mirror reference
+		return mirror
+	}
+	return api.GetImageNameOrDefault(service, project.Name)
+}
+
 // defaultNetworkSettings determines the container.NetworkMode and corresponding network.NetworkingConfig (nil if not applicable).
 func defaultNetworkSettings(
 	project *types.Project,
@@ -1028,6 +1062,10 @@ func buildContainerSecretMounts(p types.Project, s types.ServiceConfig) ([]mount
 		}
 
 		definedSecret := p.Secrets[secret.Source]
+		if _, ok := definedSecret.Extensions[api.SecretProviderExtension]; ok {
+			// injected once the container is created
+			continue
+		}
 		if definedSecret.External {
 			return nil, fmt.Errorf("unsupported external secret %s", definedSecret.Name)
 		}
@@ -1320,14 +1358,14 @@ func (s *composeService) resolveOrCreateNetwork(ctx context.Context, n *types.Ne
 	}
 	networkEventName := fmt.Sprintf("Network %s", n.Name)
 	w := progress.ContextWriter(ctx)
-	w.Event(progress.CreatingEvent(networkEventName))
+	w.Event(progress.CreatingEvent(networkEventName).WithKind(api.ProgressKindNetwork))
 
 	_, err = s.apiClient().NetworkCreate(ctx, n.Name, createOpts)
 	if err != nil {
-		w.Event(progress.ErrorEvent(networkEventName))
+		w.Event(progress.ErrorEvent(networkEventName).WithKind(api.ProgressKindNetwork))
 		return fmt.Errorf("failed to create network %s: %w", n.Name, err)
 	}
-	w.Event(progress.CreatedEvent(networkEventName))
+	w.Event(progress.CreatedEvent(networkEventName).WithKind(api.ProgressKindNetwork))
 	return nil
 }
 
@@ -1413,7 +1451,7 @@ func (s *composeService) ensureVolume(ctx context.Context, volume types.VolumeCo
 func (s *composeService) createVolume(ctx context.Context, volume types.VolumeConfig) error {
 	eventName := fmt.Sprintf("Volume %q", volume.Name)
 	w := progress.ContextWriter(ctx)
-	w.Event(progress.CreatingEvent(eventName))
+	w.Event(progress.CreatingEvent(eventName).WithKind(api.ProgressKindVolume))
 	_, err := s.apiClient().VolumeCreate(ctx, volumetypes.CreateOptions{
 		Labels:     volume.Labels,
 		Name:       volume.Name,
@@ -1421,9 +1459,9 @@ func (s *composeService) createVolume(ctx context.Context, volume types.VolumeCo
 		DriverOpts: volume.DriverOpts,
 	})
 	if err != nil {
-		w.Event(progress.ErrorEvent(eventName))
+		w.Event(progress.ErrorEvent(eventName).WithKind(api.ProgressKindVolume))
 		return err
 	}
-	w.Event(progress.CreatedEvent(eventName))
+	w.Event(progress.CreatedEvent(eventName).WithKind(api.ProgressKindVolume))
 	return nil
 }
@@ -79,6 +79,10 @@ func (s *composeService) down(ctx context.Context, projectName string, options a
 
 	options.Services = services
 
+	if err := s.runProjectHooks(ctx, project, hookPreDown, nil); err != nil {
+		return err
+	}
+
 	if len(containers) > 0 {
 		resourceToRemove = true
 	}
@@ -123,7 +127,10 @@ func (s *composeService) down(ctx context.Context, projectName string, options a
 	for _, op := range ops {
 		eg.Go(op)
 	}
-	return eg.Wait()
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	return s.runProjectHooks(ctx, project, hookPostDown, nil)
 }
 
 func checkSelectedServices(options api.DownOptions, project *types.Project) ([]string, error) {
@@ -217,7 +224,7 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 	}
 
 	eventName := fmt.Sprintf("Network %s", name)
-	w.Event(progress.RemovingEvent(eventName))
+	w.Event(progress.RemovingEvent(eventName).WithKind(api.ProgressKindNetwork))
 
 	var found int
 	for _, net := range networks {
@@ -226,14 +233,14 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 		}
 		nw, err := s.apiClient().NetworkInspect(ctx, net.ID, network.InspectOptions{})
 		if errdefs.IsNotFound(err) {
-			w.Event(progress.NewEvent(eventName, progress.Warning, "No resource found to remove"))
+			w.Event(progress.NewEvent(eventName, progress.Warning, "No resource found to remove").WithKind(api.ProgressKindNetwork))
 			return nil
 		}
 		if err != nil {
 			return err
 		}
 		if len(nw.Containers) > 0 {
-			w.Event(progress.NewEvent(eventName, progress.Warning, "Resource is still in use"))
+			w.Event(progress.NewEvent(eventName, progress.Warning, "Resource is still in use").WithKind(api.ProgressKindNetwork))
 			found++
 			continue
 		}
@@ -242,10 +249,10 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 			if errdefs.IsNotFound(err) {
 				continue
 			}
-			w.Event(progress.ErrorEvent(eventName))
+			w.Event(progress.ErrorEvent(eventName).WithKind(api.ProgressKindNetwork))
 			return fmt.Errorf("failed to remove network %s: %w", name, err)
 		}
-		w.Event(progress.RemovedEvent(eventName))
+		w.Event(progress.RemovedEvent(eventName).WithKind(api.ProgressKindNetwork))
 		found++
 	}
 
@@ -253,7 +260,7 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 		// in practice, it's extremely unlikely for this to ever occur, as it'd
 		// mean the network was present when we queried at the start of this
 		// method but was then deleted by something else in the interim
-		w.Event(progress.NewEvent(eventName, progress.Warning, "No resource found to remove"))
+		w.Event(progress.NewEvent(eventName, progress.Warning, "No resource found to remove").WithKind(api.ProgressKindNetwork))
 		return nil
 	}
 	return nil
@@ -261,18 +268,18 @@ func (s *composeService) removeNetwork(ctx context.Context, composeNetworkName s
 
 func (s *composeService) removeImage(ctx context.Context, image string, w progress.Writer) error {
 	id := fmt.Sprintf("Image %s", image)
-	w.Event(progress.NewEvent(id, progress.Working, "Removing"))
+	w.Event(progress.NewEvent(id, progress.Working, "Removing").WithKind(api.ProgressKindImage))
 	_, err := s.apiClient().ImageRemove(ctx, image, imageapi.RemoveOptions{})
 	if err == nil {
-		w.Event(progress.NewEvent(id, progress.Done, "Removed"))
+		w.Event(progress.NewEvent(id, progress.Done, "Removed").WithKind(api.ProgressKindImage))
 		return nil
 	}
 	if errdefs.IsConflict(err) {
-		w.Event(progress.NewEvent(id, progress.Warning, "Resource is still in use"))
+		w.Event(progress.NewEvent(id, progress.Warning, "Resource is still in use").WithKind(api.ProgressKindImage))
 		return nil
 	}
 	if errdefs.IsNotFound(err) {
-		w.Event(progress.NewEvent(id, progress.Done, "Warning: No resource found to remove"))
+		w.Event(progress.NewEvent(id, progress.Done, "Warning: No resource found to remove").WithKind(api.ProgressKindImage))
 		return nil
 	}
 	return err
@@ -280,18 +287,18 @@ func (s *composeService) removeImage(ctx context.Context, image string, w progre
 
 func (s *composeService) removeVolume(ctx context.Context, id string, w progress.Writer) error {
 	resource := fmt.Sprintf("Volume %s", id)
-	w.Event(progress.NewEvent(resource, progress.Working, "Removing"))
+	w.Event(progress.NewEvent(resource, progress.Working, "Removing").WithKind(api.ProgressKindVolume))
 	err := s.apiClient().VolumeRemove(ctx, id, true)
 	if err == nil {
-		w.Event(progress.NewEvent(resource, progress.Done, "Removed"))
+		w.Event(progress.NewEvent(resource, progress.Done, "Removed").WithKind(api.ProgressKindVolume))
 		return nil
 	}
 	if errdefs.IsConflict(err) {
-		w.Event(progress.NewEvent(resource, progress.Warning, "Resource is still in use"))
+		w.Event(progress.NewEvent(resource, progress.Warning, "Resource is still in use").WithKind(api.ProgressKindVolume))
 		return nil
 	}
 	if errdefs.IsNotFound(err) {
-		w.Event(progress.NewEvent(resource, progress.Done, "Warning: No resource found to remove"))
+		w.Event(progress.NewEvent(resource, progress.Done, "Warning: No resource found to remove").WithKind(api.ProgressKindVolume))
 		return nil
 	}
 	return err
@@ -299,11 +306,11 @@ func (s *composeService) removeVolume(ctx context.Context, id string, w progress
 
 func (s *composeService) stopContainer(ctx context.Context, w progress.Writer, service *types.ServiceConfig, container moby.Container, timeout *time.Duration) error {
 	eventName := getContainerProgressName(container)
-	w.Event(progress.StoppingEvent(eventName))
+	w.Event(progress.StoppingEvent(eventName).WithKind(api.ProgressKindContainerState))
 
 	if service != nil {
 		for _, hook := range service.PreStop {
-			err := s.runHook(ctx, container, *service, hook, nil)
+			err := s.runHook(ctx, container, *service, hook, "pre_stop", nil)
 			if err != nil {
 				return err
 			}
@@ -313,10 +320,10 @@ func (s *composeService) stopContainer(ctx context.Context, w progress.Writer, s
 	timeoutInSecond := utils.DurationSecondToInt(timeout)
 	err := s.apiClient().ContainerStop(ctx, container.ID, containerType.StopOptions{Timeout: timeoutInSecond})
 	if err != nil {
-		w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping"))
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Stopping").WithKind(api.ProgressKindContainerState))
 		return err
 	}
-	w.Event(progress.StoppedEvent(eventName))
+	w.Event(progress.StoppedEvent(eventName).WithKind(api.ProgressKindContainerState))
 	return nil
 }
 
@@ -347,22 +354,22 @@ func (s *composeService) stopAndRemoveContainer(ctx context.Context, container m
 	eventName := getContainerProgressName(container)
 	err := s.stopContainer(ctx, w, service, container, timeout)
 	if errdefs.IsNotFound(err) {
-		w.Event(progress.RemovedEvent(eventName))
+		w.Event(progress.RemovedEvent(eventName).WithKind(api.ProgressKindContainerState))
 		return nil
 	}
 	if err != nil {
 		return err
 	}
-	w.Event(progress.RemovingEvent(eventName))
+	w.Event(progress.RemovingEvent(eventName).WithKind(api.ProgressKindContainerState))
 	err = s.apiClient().ContainerRemove(ctx, container.ID, containerType.RemoveOptions{
 		Force:         true,
 		RemoveVolumes: volumes,
 	})
 	if err != nil && !errdefs.IsNotFound(err) && !errdefs.IsConflict(err) {
-		w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing"))
+		w.Event(progress.ErrorMessageEvent(eventName, "Error while Removing").WithKind(api.ProgressKindContainerState))
 		return err
 	}
-	w.Event(progress.RemovedEvent(eventName))
+	w.Event(progress.RemovedEvent(eventName).WithKind(api.ProgressKindContainerState))
 	return nil
 }
 
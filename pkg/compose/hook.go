@@ -18,19 +18,177 @@ package compose
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/internal/tracing"
 	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
 	"github.com/docker/compose/v2/pkg/utils"
 	moby "github.com/docker/docker/api/types"
 	containerType "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/pkg/stdcopy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func (s composeService) runHook(ctx context.Context, container moby.Container, service types.ServiceConfig, hook types.ServiceHook, listener api.ContainerEventListener) error {
+const (
+	// hookFailureFail makes a failed hook fail the operation it runs for
+	hookFailureFail = "fail"
+	// hookFailureWarn reports a failed hook as a warning, and carries on with the operation
+	hookFailureWarn = "warn"
+	// hookFailureIgnore silently carries on with the operation when a hook fails
+	hookFailureIgnore = "ignore"
+)
+
+// hookPolicy tells how long a hook may run, how many times it is retried and how its failure is handled. It is set
+// by the `timeout`, `retries`, `retry_delay` and `on_failure` hook attributes, prefixed with `x-` for container hooks
+type hookPolicy struct {
+	timeout    time.Duration
+	retries    int
+	retryDelay time.Duration
+	onFailure  string
+}
+
+func getHookPolicy(attributes map[string]any, prefix string) (hookPolicy, error) {
+	policy := hookPolicy{onFailure: hookFailureFail}
+	var err error
+	if v, ok := attributes[prefix+"timeout"]; ok {
+		if policy.timeout, err = hookDuration(v); err != nil {
+			return policy, fmt.Errorf("invalid %stimeout: %w", prefix, err)
+		}
+	}
+	if v, ok := attributes[prefix+"retries"]; ok {
+		if policy.retries, err = strconv.Atoi(fmt.Sprint(v)); err != nil || policy.retries < 0 {
+			return policy, fmt.Errorf("invalid %sretries: %v", prefix, v)
+		}
+	}
+	if v, ok := attributes[prefix+"retry_delay"]; ok {
+		if policy.retryDelay, err = hookDuration(v); err != nil {
+			return policy, fmt.Errorf("invalid %sretry_delay: %w", prefix, err)
+		}
+	}
+	if v, ok := attributes[prefix+"on_failure"]; ok {
+		switch v {
+		case hookFailureFail, hookFailureWarn, hookFailureIgnore:
+			policy.onFailure = v.(string)
+		default:
+			return policy, fmt.Errorf("invalid %son_failure %v, must be one of %s, %s or %s", prefix, v, hookFailureFail, hookFailureWarn, hookFailureIgnore)
+		}
+	}
+	return policy, nil
+}
+
+// hookDuration parses a duration set as a string, or as a number of seconds
+func hookDuration(v any) (time.Duration, error) {
+	switch d := v.(type) {
+	case string:
+		return time.ParseDuration(d)
+	case int:
+		return time.Duration(d) * time.Second, nil
+	case float64:
+		return time.Duration(d * float64(time.Second)), nil
+	default:
+		return 0, fmt.Errorf("unexpected duration %v", v)
+	}
+}
+
+// runHook runs a lifecycle hook in a container according to its policy, reporting its execution as a progress event
+func (s composeService) runHook(ctx context.Context, container moby.Container, service types.ServiceConfig, hook types.ServiceHook, kind string, listener api.ContainerEventListener) error {
+	policy, err := getHookPolicy(hook.Extensions, "x-")
+	if err != nil {
+		return fmt.Errorf("service %q %s hook: %w", service.Name, kind, err)
+	}
+	eventName := fmt.Sprintf("%s %s", getContainerProgressName(container), kind)
+	// As the engine has no API to stop an exec, the hook command may still be running in the container after it
+	// timed out, so it isn't retried not to run concurrently with itself
+	return runWithHookPolicy(ctx, eventName, policy, false, func(ctx context.Context) error {
+		return s.execHook(ctx, container, service, hook, listener)
+	})
+}
+
+// runWithHookPolicy runs a hook, retrying it and handling its failure as set by the policy. A hook which timed out is
+// only retried if retryTimeout is set, as run must then have stopped it
+func runWithHookPolicy(ctx context.Context, eventName string, policy hookPolicy, retryTimeout bool, run func(context.Context) error) error {
+	spanOpts := tracing.SpanOptions{trace.WithAttributes(
+		attribute.String("hook.name", eventName),
+		attribute.Int("hook.retries", policy.retries),
+		attribute.String("hook.on_failure", policy.onFailure),
+	)}
+	return tracing.SpanWrapFunc("hook/run", spanOpts, func(ctx context.Context) error {
+		w := progress.ContextWriter(ctx)
+		w.Event(hookEvent(eventName, progress.Working, "Running"))
+		start := time.Now()
+		var err error
+		for attempt := 0; ; attempt++ {
+			err = runWithTimeout(ctx, eventName, policy.timeout, run)
+			if err == nil || attempt >= policy.retries || ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, errHookTimeout) && !retryTimeout {
+				err = fmt.Errorf("%w, and isn't retried as it may still be running", err)
+				break
+			}
+			tracing.AddEventToSpan(ctx, "hook/retry",
+				attribute.Int("hook.attempt", attempt+1),
+				attribute.String("exception.message", err.Error()))
+			w.Event(hookEvent(eventName, progress.Working, fmt.Sprintf("Retrying (%d/%d): %s", attempt+1, policy.retries, err)))
+			select {
+			case <-ctx.Done():
+			case <-time.After(policy.retryDelay):
+			}
+		}
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err == nil {
+			w.Event(hookEvent(eventName, progress.Done, fmt.Sprintf("Completed in %s", elapsed)))
+			return nil
+		}
+
+		switch policy.onFailure {
+		case hookFailureIgnore:
+			tracing.AddEventToSpan(ctx, "hook/failed", attribute.String("exception.message", err.Error()))
+			w.Event(hookEvent(eventName, progress.Done, fmt.Sprintf("Failed in %s, ignored", elapsed)))
+			return nil
+		case hookFailureWarn:
+			tracing.AddEventToSpan(ctx, "hook/failed", attribute.String("exception.message", err.Error()))
+			w.Event(hookEvent(eventName, progress.Warning, fmt.Sprintf("Failed in %s: %s", elapsed, err)))
+			return nil
+		default:
+			w.Event(hookEvent(eventName, progress.Error, fmt.Sprintf("Failed in %s", elapsed)))
+			return err
+		}
+	})(ctx)
+}
+
+func hookEvent(id string, status progress.EventStatus, statusText string) progress.Event {
+	event := progress.NewEvent(id, status, statusText)
+	event.Kind = api.ProgressKindHook
+	return event
+}
+
+// errHookTimeout is returned by hooks which didn't complete before their timeout
+var errHookTimeout = errors.New("timed out")
+
+// runWithTimeout runs a hook once, and gives up waiting for it once the timeout, if any, expires
+func runWithTimeout(ctx context.Context, eventName string, timeout time.Duration, run func(context.Context) error) error {
+	if timeout == 0 {
+		return run(ctx)
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := run(hookCtx)
+	if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s %w after %s", eventName, errHookTimeout, timeout)
+	}
+	return err
+}
+
+// execHook runs a hook command in a container, and waits for it to complete
+func (s composeService) execHook(ctx context.Context, container moby.Container, service types.ServiceConfig, hook types.ServiceHook, listener api.ContainerEventListener) error {
 	wOut := utils.GetWriter(func(line string) {
 		listener(api.ContainerEvent{
 			Type:      api.HookEventLog,
@@ -71,6 +229,9 @@ func (s composeService) runHook(ctx context.Context, container moby.Container, s
 		return err
 	}
 	defer attach.Close()
+	// closing the connection unblocks the copy below once the context is done
+	stop := context.AfterFunc(ctx, attach.Close)
+	defer stop()
 
 	if service.Tty {
 		_, err = io.Copy(wOut, attach.Reader)
@@ -78,6 +239,9 @@ func (s composeService) runHook(ctx context.Context, container moby.Container, s
 		_, err = stdcopy.StdCopy(wOut, wOut, attach.Reader)
 	}
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return err
 	}
 
@@ -105,7 +269,7 @@ func (s composeService) runWaitExec(ctx context.Context, exec moby.IDResponse, s
 	for {
 		select {
 		case <-ctx.Done():
-			return nil
+			return ctx.Err()
 		case <-tick.C:
 			inspect, err := s.apiClient().ContainerExecInspect(ctx, exec.ID)
 			if err != nil {
@@ -0,0 +1,122 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	moby "github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetHookPolicy(t *testing.T) {
+	policy, err := getHookPolicy(nil, "x-")
+	assert.NilError(t, err)
+	assert.Equal(t, policy, hookPolicy{onFailure: hookFailureFail})
+
+	policy, err = getHookPolicy(types.Extensions{
+		"x-timeout":     "2m",
+		"x-retries":     3,
+		"x-retry_delay": 5,
+		"x-on_failure":  "warn",
+	}, "x-")
+	assert.NilError(t, err)
+	assert.Equal(t, policy, hookPolicy{timeout: 2 * time.Minute, retries: 3, retryDelay: 5 * time.Second, onFailure: hookFailureWarn})
+
+	_, err = getHookPolicy(types.Extensions{"x-timeout": "soon"}, "x-")
+	assert.ErrorContains(t, err, "invalid x-timeout")
+	_, err = getHookPolicy(types.Extensions{"x-retries": -1}, "x-")
+	assert.ErrorContains(t, err, "invalid x-retries")
+	_, err = getHookPolicy(types.Extensions{"x-on_failure": "retry"}, "x-")
+	assert.ErrorContains(t, err, "invalid x-on_failure retry")
+}
+
+func TestRunHookRetries(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	ctr := testContainer("db", "123", false)
+	hook := types.ServiceHook{Command: []string{"migrate"}, Extensions: types.Extensions{"x-retries": 1}}
+	api.EXPECT().ContainerExecCreate(gomock.Any(), "123", gomock.Any()).Return(moby.IDResponse{ID: "exec"}, nil).Times(2)
+	api.EXPECT().ContainerExecStart(gomock.Any(), "exec", gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		api.EXPECT().ContainerExecInspect(gomock.Any(), "exec").Return(containerType.ExecInspect{ExitCode: 1}, nil),
+		api.EXPECT().ContainerExecInspect(gomock.Any(), "exec").Return(containerType.ExecInspect{ExitCode: 0}, nil),
+	)
+
+	err := tested.runHook(context.Background(), ctr, types.ServiceConfig{Name: "db"}, hook, "post_start", nil)
+	assert.NilError(t, err)
+}
+
+func TestRunHookFailurePolicy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	ctr := testContainer("db", "123", false)
+	api.EXPECT().ContainerExecCreate(gomock.Any(), "123", gomock.Any()).Return(moby.IDResponse{ID: "exec"}, nil).Times(2)
+	api.EXPECT().ContainerExecStart(gomock.Any(), "exec", gomock.Any()).Return(nil).Times(2)
+	api.EXPECT().ContainerExecInspect(gomock.Any(), "exec").Return(containerType.ExecInspect{ExitCode: 2}, nil).Times(2)
+
+	err := tested.runHook(context.Background(), ctr, types.ServiceConfig{Name: "db"},
+		types.ServiceHook{Command: []string{"migrate"}, Extensions: types.Extensions{"x-on_failure": "warn"}}, "post_start", nil)
+	assert.NilError(t, err)
+
+	err = tested.runHook(context.Background(), ctr, types.ServiceConfig{Name: "db"},
+		types.ServiceHook{Command: []string{"migrate"}}, "post_start", nil)
+	assert.Error(t, err, "db hook exited with status 2")
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	ctr := testContainer("db", "123", false)
+	api.EXPECT().ContainerExecCreate(gomock.Any(), "123", gomock.Any()).Return(moby.IDResponse{ID: "exec"}, nil)
+	api.EXPECT().ContainerExecStart(gomock.Any(), "exec", gomock.Any()).Return(nil)
+	api.EXPECT().ContainerExecInspect(gomock.Any(), "exec").Return(containerType.ExecInspect{Running: true}, nil).AnyTimes()
+
+	hook := types.ServiceHook{Command: []string{"migrate"}, Extensions: types.Extensions{"x-timeout": "250ms"}}
+	err := tested.runHook(context.Background(), ctr, types.ServiceConfig{Name: "db"}, hook, "post_start", nil)
+	assert.Error(t, err, "Container 123 post_start timed out after 250ms")
+}
+
+func TestRunHookTimeoutNotRetried(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	ctr := testContainer("db", "123", false)
+	api.EXPECT().ContainerExecCreate(gomock.Any(), "123", gomock.Any()).Return(moby.IDResponse{ID: "exec"}, nil).Times(1)
+	api.EXPECT().ContainerExecStart(gomock.Any(), "exec", gomock.Any()).Return(nil).Times(1)
+	api.EXPECT().ContainerExecInspect(gomock.Any(), "exec").Return(containerType.ExecInspect{Running: true}, nil).AnyTimes()
+
+	hook := types.ServiceHook{Command: []string{"migrate"}, Extensions: types.Extensions{"x-timeout": "100ms", "x-retries": 2}}
+	err := tested.runHook(context.Background(), ctr, types.ServiceConfig{Name: "db"}, hook, "post_start", nil)
+	assert.Error(t, err, "Container 123 post_start timed out after 100ms, and isn't retried as it may still be running")
+}
@@ -0,0 +1,226 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/compose-spec/compose-go/v2/types"
+
+	"github.com/docker/compose/v2/cmd/formatter"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/compose/v2/pkg/utils"
+)
+
+const (
+	// hostHooksExtension is the project and service extension declaring the hooks to run on the host
+	hostHooksExtension = "x-hooks"
+
+	hookPreUp     = "pre_up"
+	hookPostUp    = "post_up"
+	hookPreDown   = "pre_down"
+	hookPostDown  = "post_down"
+	hookPreBuild  = "pre_build"
+	hookPostBuild = "post_build"
+)
+
+var (
+	projectHookKinds = []string{hookPreUp, hookPostUp, hookPreDown, hookPostDown}
+	serviceHookKinds = []string{hookPreBuild, hookPostBuild}
+)
+
+// hostHook is a command run on the host around a Compose operation, with the project environment and working
+// directory. Hooks are declared by kind with the `x-hooks` extension, either as a command or as a list of hooks:
+//
+//	x-hooks:
+//	  pre_up: ./scripts/generate-certs.sh
+//	  post_down:
+//	    - command: ["./scripts/dns.sh", "unregister"]
+//	      timeout: 10s
+//	      on_failure: warn
+type hostHook struct {
+	command     []string
+	workingDir  string
+	environment types.Mapping
+	policy      hookPolicy
+}
+
+// getHostHooks returns the hooks of a kind declared by the x-hooks extension of a project or service
+func getHostHooks(extensions types.Extensions, kinds []string, kind string) ([]hostHook, error) {
+	declared, ok := extensions[hostHooksExtension].(map[string]any)
+	if !ok {
+		if _, set := extensions[hostHooksExtension]; set {
+			return nil, fmt.Errorf("invalid %s: hooks must be declared by kind", hostHooksExtension)
+		}
+		return nil, nil
+	}
+	for k := range declared {
+		if !utils.StringContains(kinds, k) {
+			return nil, fmt.Errorf("invalid %s: unsupported hook %q", hostHooksExtension, k)
+		}
+	}
+
+	var values []any
+	switch v := declared[kind].(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		values = v
+	default:
+		values = []any{v}
+	}
+	var hooks []hostHook
+	for _, value := range values {
+		hook, err := parseHostHook(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s hook: %w", hostHooksExtension, kind, err)
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+func parseHostHook(value any) (hostHook, error) {
+	attributes, ok := value.(map[string]any)
+	if !ok {
+		attributes = map[string]any{"command": value}
+	}
+	var (
+		hook hostHook
+		err  error
+	)
+	if hook.command, err = parseCommand(attributes["command"]); err != nil {
+		return hook, err
+	}
+	if hook.policy, err = getHookPolicy(attributes, ""); err != nil {
+		return hook, err
+	}
+	if dir, ok := attributes["working_dir"]; ok {
+		hook.workingDir = fmt.Sprint(dir)
+	}
+	switch env := attributes["environment"].(type) {
+	case nil:
+	case map[string]any:
+		hook.environment = types.Mapping{}
+		for k, v := range env {
+			hook.environment[k] = fmt.Sprint(v)
+		}
+	case []any:
+		var values []string
+		for _, v := range env {
+			values = append(values, fmt.Sprint(v))
+		}
+		hook.environment = types.NewMapping(values)
+	default:
+		return hook, fmt.Errorf("invalid environment %v", env)
+	}
+	return hook, nil
+}
+
+// checkHostCommands checks a project can run a command on the host: users have to opt in, and the project must not
+// be loaded from a remote source they don't trust
+func (s *composeService) checkHostCommands(project *types.Project, what string) error {
+	if !s.hostCommands {
+		return fmt.Errorf("%s runs a command on the host, use --allow-host-commands or set COMPOSE_ALLOW_HOST_COMMANDS to allow it", what)
+	}
+	if source, ok := project.Extensions[api.UntrustedSourceExtension]; ok {
+		return fmt.Errorf("%s runs a command on the host, which is refused as the project is loaded from %s which isn't trusted", what, source)
+	}
+	return nil
+}
+
+// runProjectHooks runs the hooks of a kind declared by the project
+func (s *composeService) runProjectHooks(ctx context.Context, project *types.Project, kind string, consumer api.LogConsumer) error {
+	hooks, err := getHostHooks(project.Extensions, projectHookKinds, kind)
+	if err != nil {
+		return err
+	}
+	return s.runHostHooks(ctx, project, project.Name, hooks, kind, consumer)
+}
+
+// runServiceHooks runs the hooks of a kind declared by a service
+func (s *composeService) runServiceHooks(ctx context.Context, project *types.Project, service types.ServiceConfig, kind string, consumer api.LogConsumer) error {
+	hooks, err := getHostHooks(service.Extensions, serviceHookKinds, kind)
+	if err != nil {
+		return fmt.Errorf("service %q: %w", service.Name, err)
+	}
+	return s.runHostHooks(ctx, project, service.Name, hooks, kind, consumer)
+}
+
+func (s *composeService) runHostHooks(ctx context.Context, project *types.Project, name string, hooks []hostHook, kind string, consumer api.LogConsumer) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	if err := s.checkHostCommands(project, fmt.Sprintf("%s %s hook", name, kind)); err != nil {
+		return err
+	}
+	if consumer == nil {
+		consumer = formatter.NewLogConsumer(ctx, s.stdout(), s.stderr(), false, true, false)
+	}
+	for i, hook := range hooks {
+		eventName := fmt.Sprintf("%s %s", name, kind)
+		if len(hooks) > 1 {
+			eventName = fmt.Sprintf("%s %s #%d", name, kind, i+1)
+		}
+		if s.dryRun {
+			progress.ContextWriter(ctx).Event(hookEvent(eventName, progress.Warning, "Skipped: dry-run mode"))
+			continue
+		}
+		// the hook command is killed once it times out, so it can be retried
+		err := runWithHookPolicy(ctx, eventName, hook.policy, true, func(ctx context.Context) error {
+			return runHostHook(ctx, project, hook, name+" "+kind, consumer)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHostHook runs a hook command on the host, and streams its output to the log consumer
+func runHostHook(ctx context.Context, project *types.Project, hook hostHook, name string, consumer api.LogConsumer) error {
+	stdout := utils.GetWriter(func(line string) {
+		consumer.Log(name, line)
+	})
+	defer stdout.Close() //nolint:errcheck
+	stderr := utils.GetWriter(func(line string) {
+		consumer.Err(name, line)
+	})
+	defer stderr.Close() //nolint:errcheck
+
+	cmd := exec.CommandContext(ctx, hook.command[0], hook.command[1:]...)
+	cmd.Dir = project.WorkingDir
+	if hook.workingDir != "" {
+		cmd.Dir = hook.workingDir
+		if !filepath.IsAbs(cmd.Dir) {
+			cmd.Dir = filepath.Join(project.WorkingDir, cmd.Dir)
+		}
+	}
+	cmd.Env = append(os.Environ(), project.Environment.Values()...)
+	cmd.Env = append(cmd.Env, hook.environment.Values()...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook %q failed: %w", name, hook.command[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+func TestGetHostHooks(t *testing.T) {
+	extensions := types.Extensions{
+		"x-hooks": map[string]any{
+			"pre_up": "./certs.sh --force",
+			"post_up": []any{
+				[]any{"dns", "register"},
+				map[string]any{
+					"command":     "./seed.sh",
+					"working_dir": "fixtures",
+					"environment": []any{"SEED=1"},
+					"timeout":     "1m",
+					"on_failure":  "warn",
+				},
+			},
+		},
+	}
+	hooks, err := getHostHooks(extensions, projectHookKinds, hookPreUp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hooks), 1)
+	assert.DeepEqual(t, hooks[0].command, []string{"./certs.sh", "--force"})
+	assert.Equal(t, hooks[0].policy, hookPolicy{onFailure: hookFailureFail})
+
+	hooks, err = getHostHooks(extensions, projectHookKinds, hookPostUp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hooks), 2)
+	assert.DeepEqual(t, hooks[0].command, []string{"dns", "register"})
+	assert.DeepEqual(t, hooks[1].command, []string{"./seed.sh"})
+	assert.Equal(t, hooks[1].workingDir, "fixtures")
+	assert.DeepEqual(t, hooks[1].environment, types.Mapping{"SEED": "1"})
+	assert.Equal(t, hooks[1].policy, hookPolicy{timeout: time.Minute, onFailure: hookFailureWarn})
+
+	hooks, err = getHostHooks(extensions, projectHookKinds, hookPreDown)
+	assert.NilError(t, err)
+	assert.Equal(t, len(hooks), 0)
+
+	_, err = getHostHooks(extensions, serviceHookKinds, hookPreBuild)
+	assert.ErrorContains(t, err, "invalid x-hooks: unsupported hook")
+	_, err = getHostHooks(types.Extensions{"x-hooks": map[string]any{"pre_up": map[string]any{"retries": 1}}}, projectHookKinds, hookPreUp)
+	assert.ErrorContains(t, err, "command is required")
+}
+
+func TestRunHostHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	dir := t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "fixtures"), 0o755))
+	project := &types.Project{
+		Name:        "demo",
+		WorkingDir:  dir,
+		Environment: types.Mapping{"DOMAIN": "demo.local"},
+		Extensions: types.Extensions{
+			"x-hooks": map[string]any{
+				"pre_up": []any{
+					[]any{"sh", "-c", `echo "register $DOMAIN"`},
+					[]any{"sh", "-c", `echo "seeding $SEED" >&2`},
+					map[string]any{
+						"command":     []any{"sh", "-c", "basename $PWD"},
+						"working_dir": "fixtures",
+						"environment": map[string]any{"SEED": "1"},
+					},
+				},
+				"post_up":   map[string]any{"command": []any{"sh", "-c", "exit 3"}, "on_failure": "warn"},
+				"pre_down":  map[string]any{"command": []any{"sh", "-c", "exit 3"}, "retries": 1},
+				"post_down": map[string]any{"command": []any{"sleep", "10"}, "timeout": "100ms"},
+			},
+		},
+	}
+	tested := composeService{hostCommands: true}
+	consumer := &testLogConsumer{}
+
+	err := tested.runProjectHooks(context.Background(), project, hookPreUp, consumer)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, consumer.LogsForContainer("demo pre_up"), []string{"register demo.local", "seeding ", "fixtures"})
+
+	err = tested.runProjectHooks(context.Background(), project, hookPostUp, consumer)
+	assert.NilError(t, err)
+
+	err = tested.runProjectHooks(context.Background(), project, hookPreDown, consumer)
+	assert.ErrorContains(t, err, `demo pre_down hook "sh" failed: exit status 3`)
+
+	err = tested.runProjectHooks(context.Background(), project, hookPostDown, consumer)
+	assert.Error(t, err, "demo post_down timed out after 100ms")
+}
+
+func TestCheckHostCommands(t *testing.T) {
+	project := &types.Project{
+		Name: "demo",
+		Extensions: types.Extensions{
+			"x-hooks": map[string]any{"pre_up": "./certs.sh"},
+		},
+	}
+	tested := composeService{}
+	err := tested.runProjectHooks(context.Background(), project, hookPreUp, &testLogConsumer{})
+	assert.ErrorContains(t, err, "demo pre_up hook runs a command on the host, use --allow-host-commands")
+
+	tested.hostCommands = true
+	project.Extensions[api.UntrustedSourceExtension] = "oci://registry.example.com/demo"
+	err = tested.runProjectHooks(context.Background(), project, hookPreUp, &testLogConsumer{})
+	assert.ErrorContains(t, err, "the project is loaded from oci://registry.example.com/demo which isn't trusted")
+}
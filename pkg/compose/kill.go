@@ -66,13 +66,13 @@ func (s *composeService) kill(ctx context.Context, projectName string, options a
 		forEach(func(container moby.Container) {
 			eg.Go(func() error {
 				eventName := getContainerProgressName(container)
-				w.Event(progress.KillingEvent(eventName))
+				w.Event(progress.KillingEvent(eventName).WithKind(api.ProgressKindContainerState))
 				err := s.apiClient().ContainerKill(ctx, container.ID, options.Signal)
 				if err != nil {
-					w.Event(progress.ErrorMessageEvent(eventName, "Error while Killing"))
+					w.Event(progress.ErrorMessageEvent(eventName, "Error while Killing").WithKind(api.ProgressKindContainerState))
 					return err
 				}
-				w.Event(progress.KilledEvent(eventName))
+				w.Event(progress.KilledEvent(eventName).WithKind(api.ProgressKindContainerState))
 				return nil
 			})
 		})
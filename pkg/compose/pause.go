@@ -50,7 +50,7 @@ func (s *composeService) pause(ctx context.Context, projectName string, options
 			err := s.apiClient().ContainerPause(ctx, container.ID)
 			if err == nil {
 				eventName := getContainerProgressName(container)
-				w.Event(progress.NewEvent(eventName, progress.Done, "Paused"))
+				w.Event(progress.NewEvent(eventName, progress.Done, "Paused").WithKind(api.ProgressKindContainerState))
 			}
 			return err
 		})
@@ -82,7 +82,7 @@ func (s *composeService) unPause(ctx context.Context, projectName string, option
 			err = s.apiClient().ContainerUnpause(ctx, container.ID)
 			if err == nil {
 				eventName := getContainerProgressName(container)
-				w.Event(progress.NewEvent(eventName, progress.Done, "Unpaused"))
+				w.Event(progress.NewEvent(eventName, progress.Done, "Unpaused").WithKind(api.ProgressKindContainerState))
 			}
 			return err
 		})
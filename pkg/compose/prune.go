@@ -0,0 +1,133 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/compose/v2/pkg/prompt"
+)
+
+func (s *composeService) Prune(ctx context.Context, projectName string, options api.PruneOptions) error {
+	projectName = strings.ToLower(projectName)
+	if !options.OneOff {
+		return nil
+	}
+
+	stale, err := s.getStaleOneOffContainers(ctx, projectName, options.OlderThan)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		_, _ = fmt.Fprintln(s.stdinfo(), "No stale one-off containers")
+		return nil
+	}
+
+	var names []string
+	stale.forEach(func(c moby.Container) {
+		names = append(names, getCanonicalContainerName(c))
+	})
+	msg := fmt.Sprintf("Going to remove %s and their anonymous volumes", strings.Join(names, ", "))
+	if options.Force {
+		_, _ = fmt.Fprintln(s.stdout(), msg)
+	} else {
+		confirm, err := prompt.NewPrompt(s.stdin(), s.stdout()).Confirm(msg, false)
+		if err != nil {
+			return err
+		}
+		if !confirm {
+			return nil
+		}
+	}
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return s.remove(ctx, stale, api.RemoveOptions{Volumes: true})
+	}, s.stdinfo(), "Removing")
+}
+
+const (
+	// gcOlderThan is how long a one-off container must have been stopped for `compose run --gc` to remove it, as a
+	// concurrent run may have just stopped it and still be reading its exit code or removing it
+	gcOlderThan = time.Minute
+	// createdOlderThan is how long a one-off container which never started must have been created to be stale, as a
+	// concurrent `compose run` may have created it and not started it yet
+	createdOlderThan = 10 * time.Minute
+)
+
+// removeStaleOneOffContainers removes the stopped one-off containers of a project, without asking for confirmation as
+// `compose run --gc` explicitly asks for it
+func (s *composeService) removeStaleOneOffContainers(ctx context.Context, projectName string) error {
+	stale, err := s.getStaleOneOffContainers(ctx, projectName, gcOlderThan)
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return s.remove(ctx, stale, api.RemoveOptions{Volumes: true})
+	}, s.stdinfo(), "Removing")
+}
+
+// getStaleOneOffContainers returns the one-off containers of a project created by `compose run`, either without --rm
+// or by a run which crashed, that have been stopped for longer than olderThan. Containers which never started are
+// only stale once created for longer than createdOlderThan too
+func (s *composeService) getStaleOneOffContainers(ctx context.Context, projectName string, olderThan time.Duration) (Containers, error) {
+	containers, err := s.getContainers(ctx, projectName, oneOffOnly, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var stale Containers
+	for _, container := range containers {
+		// We have to inspect containers, as State reported by getContainers suffers a race condition
+		inspected, err := s.apiClient().ContainerInspect(ctx, container.ID)
+		if errdefs.IsNotFound(err) {
+			// Already removed, as the run completed with --rm
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if inspected.State == nil || inspected.State.Running || inspected.State.Restarting {
+			continue
+		}
+		threshold := olderThan
+		if inspected.State.Status == ContainerCreated {
+			threshold = max(olderThan, createdOlderThan)
+		}
+		if now.Sub(stoppedAt(inspected)) < threshold {
+			continue
+		}
+		stale = append(stale, container)
+	}
+	return stale, nil
+}
+
+// stoppedAt returns the time a container stopped, or was created if it never started
+func stoppedAt(container moby.ContainerJSON) time.Time {
+	finished, err := time.Parse(time.RFC3339Nano, container.State.FinishedAt)
+	if err == nil && !finished.IsZero() {
+		return finished
+	}
+	created, _ := time.Parse(time.RFC3339Nano, container.Created)
+	return created
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	moby "github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/errdefs"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	compose "github.com/docker/compose/v2/pkg/api"
+)
+
+func TestPruneOneOff(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{
+		dockerCli: cli,
+	}
+
+	api.EXPECT().ContainerList(gomock.Any(), containerType.ListOptions{
+		Filters: filters.NewArgs(
+			projectFilter(strings.ToLower(testProject)),
+			hasConfigHashLabel(),
+			oneOffFilter(true),
+		),
+		All: true,
+	}).Return([]moby.Container{
+		testContainer("service1", "running", true),
+		testContainer("service1", "stopped-long-ago", true),
+		testContainer("service1", "stopped-recently", true),
+		testContainer("service1", "never-started", true),
+		testContainer("service1", "just-created", true),
+		testContainer("service1", "auto-removed", true),
+	}, nil)
+
+	now := time.Now()
+	api.EXPECT().ContainerInspect(gomock.Any(), "running").Return(inspectOneOff(true, now.Add(-72*time.Hour), time.Time{}), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "stopped-long-ago").Return(inspectOneOff(false, now.Add(-72*time.Hour), now.Add(-48*time.Hour)), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "stopped-recently").Return(inspectOneOff(false, now.Add(-72*time.Hour), now.Add(-time.Hour)), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "never-started").Return(inspectOneOff(false, now.Add(-30*time.Hour), time.Time{}), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "just-created").Return(inspectOneOff(false, now.Add(-time.Minute), time.Time{}), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "auto-removed").Return(moby.ContainerJSON{}, errdefs.NotFound(errors.New("no such container")))
+
+	removeOptions := containerType.RemoveOptions{RemoveVolumes: true}
+	api.EXPECT().ContainerRemove(gomock.Any(), "stopped-long-ago", removeOptions).Return(nil)
+	api.EXPECT().ContainerRemove(gomock.Any(), "never-started", removeOptions).Return(nil)
+
+	err := tested.Prune(context.Background(), strings.ToLower(testProject), compose.PruneOptions{
+		OneOff:    true,
+		OlderThan: 24 * time.Hour,
+		Force:     true,
+	})
+	assert.NilError(t, err)
+}
+
+func TestRemoveStaleOneOffContainers(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{
+		dockerCli: cli,
+	}
+
+	api.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]moby.Container{
+		testContainer("service1", "stopped-recently", true),
+		testContainer("service1", "stopped-long-ago", true),
+		testContainer("service1", "being-started", true),
+		testContainer("service1", "never-started", true),
+	}, nil)
+
+	now := time.Now()
+	api.EXPECT().ContainerInspect(gomock.Any(), "stopped-recently").Return(inspectOneOff(false, now.Add(-time.Hour), now.Add(-time.Second)), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "stopped-long-ago").Return(inspectOneOff(false, now.Add(-time.Hour), now.Add(-10*time.Minute)), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "being-started").Return(inspectOneOff(false, now.Add(-2*time.Minute), time.Time{}), nil)
+	api.EXPECT().ContainerInspect(gomock.Any(), "never-started").Return(inspectOneOff(false, now.Add(-time.Hour), time.Time{}), nil)
+
+	// a concurrent run may still be handling the containers it just created or stopped
+	removeOptions := containerType.RemoveOptions{RemoveVolumes: true}
+	api.EXPECT().ContainerRemove(gomock.Any(), "stopped-long-ago", removeOptions).Return(nil)
+	api.EXPECT().ContainerRemove(gomock.Any(), "never-started", removeOptions).Return(nil)
+
+	assert.NilError(t, tested.removeStaleOneOffContainers(context.Background(), strings.ToLower(testProject)))
+}
+
+func inspectOneOff(running bool, created, finished time.Time) moby.ContainerJSON {
+	status := ContainerExited
+	switch {
+	case running:
+		status = ContainerRunning
+	case finished.IsZero():
+		status = ContainerCreated
+	}
+	return moby.ContainerJSON{ContainerJSONBase: &moby.ContainerJSONBase{
+		Created: created.Format(time.RFC3339Nano),
+		State: &moby.ContainerState{
+			Status:     status,
+			Running:    running,
+			FinishedAt: finished.Format(time.RFC3339Nano),
+		},
+	}}
+}
@@ -17,8 +17,16 @@
 package compose
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/distribution/reference"
@@ -26,15 +34,35 @@ import (
 	"github.com/docker/compose/v2/internal/ocipush"
 	"github.com/docker/compose/v2/pkg/api"
 	"github.com/docker/compose/v2/pkg/progress"
+	"github.com/docker/compose/v2/pkg/prompt"
+	"github.com/docker/compose/v2/pkg/remote"
+	"github.com/opencontainers/go-digest"
 )
 
 func (s *composeService) Publish(ctx context.Context, project *types.Project, repository string, options api.PublishOptions) error {
+	files, err := projectFileLayers(project, options.Includes)
+	if err != nil {
+		return err
+	}
+	if err := s.confirmPublishedFiles(files, options.AssumeYes); err != nil {
+		return err
+	}
+	if err := s.printSourceChanges(project, files); err != nil {
+		return err
+	}
+	var signer crypto.Signer
+	if options.SigningKey != "" {
+		signer, err = ocipush.LoadSigningKey(options.SigningKey)
+		if err != nil {
+			return err
+		}
+	}
 	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
-		return s.publish(ctx, project, repository, options)
+		return s.publish(ctx, project, repository, files, signer, options)
 	}, s.stdinfo(), "Publishing")
 }
 
-func (s *composeService) publish(ctx context.Context, project *types.Project, repository string, options api.PublishOptions) error {
+func (s *composeService) publish(ctx context.Context, project *types.Project, repository string, files []ocipush.Pushable, signer crypto.Signer, options api.PublishOptions) error {
 	err := s.Push(ctx, project, api.PushOptions{IgnoreFailures: true, ImageMandatory: true})
 	if err != nil {
 		return err
@@ -75,6 +103,7 @@ func (s *composeService) publish(ctx context.Context, project *types.Project, re
 			Data:       yaml,
 		})
 	}
+	layers = append(layers, files...)
 
 	w := progress.ContextWriter(ctx)
 	w.Event(progress.Event{
@@ -83,7 +112,7 @@ func (s *composeService) publish(ctx context.Context, project *types.Project, re
 		Status: progress.Working,
 	})
 	if !s.dryRun {
-		err = ocipush.PushManifest(ctx, resolver, named, layers, options.OCIVersion)
+		err = ocipush.PushManifest(ctx, resolver, named, layers, options.OCIVersion, signer)
 		if err != nil {
 			w.Event(progress.Event{
 				ID:     repository,
@@ -120,3 +149,193 @@ func (s *composeService) generateImageDigestsOverride(ctx context.Context, proje
 	}
 	return override.MarshalYAML()
 }
+
+// projectFileLayers returns the layers for the local files the project relies on: included Compose files, services'
+// env files and file-based configs, so the published project can be deployed on its own
+func projectFileLayers(project *types.Project, includes []string) ([]ocipush.Pushable, error) {
+	var layers []ocipush.Pushable
+	seen := map[string]bool{}
+	add := func(mediaType string, file string) error {
+		rel, err := filepath.Rel(project.WorkingDir, file)
+		if err != nil || !filepath.IsLocal(rel) {
+			return fmt.Errorf("%s is outside of the project directory and can't be published", file)
+		}
+		if seen[rel] {
+			return nil
+		}
+		seen[rel] = true
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, ocipush.Pushable{
+			Descriptor: ocipush.DescriptorForProjectFile(mediaType, rel, content),
+			Data:       content,
+		})
+		return nil
+	}
+
+	for _, file := range includes {
+		if err := add(ocipush.ComposeIncludeMediaType, file); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range project.ServiceNames() {
+		for _, envFile := range project.Services[name].EnvFiles {
+			if _, err := os.Stat(envFile.Path); errors.Is(err, os.ErrNotExist) && !envFile.Required {
+				continue
+			}
+			if err := add(ocipush.ComposeEnvFileMediaType, envFile.Path); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var configs []string
+	for name := range project.Configs {
+		configs = append(configs, name)
+	}
+	sort.Strings(configs)
+	for _, name := range configs {
+		config := project.Configs[name]
+		if config.File == "" {
+			continue
+		}
+		if err := add(ocipush.ComposeConfigFileMediaType, config.File); err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// confirmPublishedFiles asks the user to confirm publishing project files which look like they contain secrets, or
+// refuses to publish them when not running interactively
+func (s *composeService) confirmPublishedFiles(files []ocipush.Pushable, assumeYes bool) error {
+	var suspicious []string
+	for _, file := range files {
+		if reason, ok := looksLikeSecret(file.Data); ok {
+			suspicious = append(suspicious, fmt.Sprintf("%s (%s)", file.Descriptor.Annotations[ocipush.ComposePathAnnotation], reason))
+		}
+	}
+	if len(suspicious) == 0 || assumeYes {
+		return nil
+	}
+	msg := fmt.Sprintf("%s may contain secrets", strings.Join(suspicious, ", "))
+	if !s.stdin().IsTerminal() {
+		return fmt.Errorf("refusing to publish project files: %s. Use --yes to publish them anyway", msg)
+	}
+	confirm, err := prompt.NewPrompt(s.stdin(), s.stdout()).Confirm(msg+". Publish them anyway?", false)
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		return api.ErrCanceled
+	}
+	return nil
+}
+
+// printSourceChanges shows the files which changed since the project was pulled by `pull-project`, if it was
+func (s *composeService) printSourceChanges(project *types.Project, files []ocipush.Pushable) error {
+	source, err := remote.ReadProjectSource(project.WorkingDir)
+	if err != nil || source == nil {
+		return err
+	}
+	published := map[string]string{}
+	for _, file := range project.ComposeFiles {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(project.WorkingDir, file)
+		if err != nil {
+			return err
+		}
+		published[filepath.ToSlash(rel)] = digest.FromBytes(content).String()
+	}
+	for _, file := range files {
+		published[file.Descriptor.Annotations[ocipush.ComposePathAnnotation]] = file.Descriptor.Digest.String()
+	}
+
+	changes := sourceChanges(source, published, func(path string) bool {
+		_, err := os.Stat(filepath.Join(project.WorkingDir, filepath.FromSlash(path)))
+		return err == nil
+	})
+	out := s.stdinfo()
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintf(out, "No changes since %s (%s)\n", source.Reference, source.Digest)
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Changes since %s (%s):\n", source.Reference, source.Digest)
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(out, "  %s\n", change)
+	}
+	return nil
+}
+
+// sourceChanges lists the published files added or modified since the project was pulled, and the pulled files which
+// have been removed
+func sourceChanges(source *remote.ProjectSource, published map[string]string, exists func(path string) bool) []string {
+	var changes []string
+	for path, d := range published {
+		recorded, ok := source.Files[path]
+		switch {
+		case !ok:
+			changes = append(changes, "added:    "+path)
+		case recorded != d:
+			changes = append(changes, "modified: "+path)
+		}
+	}
+	for path := range source.Files {
+		if _, ok := published[path]; !ok && !exists(path) {
+			changes = append(changes, "removed:  "+path)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i][10:] < changes[j][10:]
+	})
+	return changes
+}
+
+// secretKeyPatterns are the fragments of variable names which are likely to hold a secret value
+var secretKeyPatterns = []string{
+	"PASSWORD",
+	"PASSWD",
+	"SECRET",
+	"TOKEN",
+	"API_KEY",
+	"APIKEY",
+	"ACCESS_KEY",
+	"PRIVATE_KEY",
+	"CREDENTIAL",
+}
+
+// looksLikeSecret detects private keys and variables with a secret-like name set to a literal value
+func looksLikeSecret(content []byte) (string, bool) {
+	if bytes.Contains(content, []byte("PRIVATE KEY-----")) {
+		return "private key", true
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			key, value, ok = strings.Cut(line, ":")
+		}
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(key, "export ")))
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if value == "" || strings.HasPrefix(value, "$") {
+			continue
+		}
+		for _, pattern := range secretKeyPatterns {
+			if strings.Contains(key, pattern) {
+				return key + " is set", true
+			}
+		}
+	}
+	return "", false
+}
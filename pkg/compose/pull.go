@@ -23,7 +23,14 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
 	"github.com/distribution/reference"
@@ -31,6 +38,7 @@ import (
 	"github.com/docker/cli/cli/config/configfile"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/registry"
 	"github.com/hashicorp/go-multierror"
@@ -58,7 +66,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 
 	w := progress.ContextWriter(ctx)
 	eg, ctx := errgroup.WithContext(ctx)
-	eg.SetLimit(s.maxConcurrency)
+	eg.SetLimit(s.pullConcurrency(opts))
 
 	var (
 		mustBuild         []string
@@ -66,11 +74,19 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		imagesBeingPulled = map[string]string{}
 	)
 
+	var services []types.ServiceConfig
+	for _, service := range project.Services {
+		services = append(services, service)
+	}
+
+	defaultPlatform := project.Environment["DOCKER_DEFAULT_PLATFORM"]
 	i := 0
-	for name, service := range project.Services {
+	for _, service := range pullOrder(project, services) {
+		name := service.Name
 		if service.Image == "" {
 			w.Event(progress.Event{
 				ID:     name,
+				Kind:   api.ProgressKindImage,
 				Status: progress.Done,
 				Text:   "Skipped - No image to be pulled",
 			})
@@ -81,6 +97,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		case types.PullPolicyNever, types.PullPolicyBuild:
 			w.Event(progress.Event{
 				ID:     name,
+				Kind:   api.ProgressKindImage,
 				Status: progress.Done,
 				Text:   "Skipped",
 			})
@@ -89,6 +106,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 			if imageAlreadyPresent(service.Image, images) {
 				w.Event(progress.Event{
 					ID:     name,
+					Kind:   api.ProgressKindImage,
 					Status: progress.Done,
 					Text:   "Skipped - Image is already present locally",
 				})
@@ -99,6 +117,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		if service.Build != nil && opts.IgnoreBuildable {
 			w.Event(progress.Event{
 				ID:     name,
+				Kind:   api.ProgressKindImage,
 				Status: progress.Done,
 				Text:   "Skipped - Image can be built",
 			})
@@ -108,6 +127,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 		if s, ok := imagesBeingPulled[service.Image]; ok {
 			w.Event(progress.Event{
 				ID:     name,
+				Kind:   api.ProgressKindImage,
 				Status: progress.Done,
 				Text:   fmt.Sprintf("Skipped - Image is already being pulled by %v", s),
 			})
@@ -118,7 +138,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 
 		idx, name, service := i, name, service
 		eg.Go(func() error {
-			_, err := s.pullServiceImage(ctx, service, s.configFile(), w, false, project.Environment["DOCKER_DEFAULT_PLATFORM"])
+			_, _, err := s.pullServiceImage(ctx, service, s.configFile(), w, false, defaultPlatform, opts.Retry)
 			if err != nil {
 				pullErrors[idx] = err
 				if service.Build != nil {
@@ -128,6 +148,7 @@ func (s *composeService) pull(ctx context.Context, project *types.Project, opts
 					if s.dryRun {
 						w.Event(progress.Event{
 							ID:     name,
+							Kind:   api.ProgressKindImage,
 							Status: progress.Error,
 							Text:   fmt.Sprintf(" - Pull error for image: %s", service.Image),
 						})
@@ -177,21 +198,19 @@ func getUnwrappedErrorMessage(err error) string {
 	return err.Error()
 }
 
+// pullServiceImage pulls the image of a service, from a mirror if the origin registry fails for a transient reason. It
+// returns the pulled image ID, and the mirror reference if an image pinned by digest came from a mirror.
 func (s *composeService) pullServiceImage(ctx context.Context, service types.ServiceConfig,
-	configFile driver.Auth, w progress.Writer, quietPull bool, defaultPlatform string) (string, error) {
+	configFile driver.Auth, w progress.Writer, quietPull bool, defaultPlatform string, retry api.PullRetryPolicy) (string, string, error) {
 	w.Event(progress.Event{
 		ID:     service.Name,
+		Kind:   api.ProgressKindImage,
 		Status: progress.Working,
 		Text:   "Pulling",
 	})
 	ref, err := reference.ParseNormalizedNamed(service.Image)
 	if err != nil {
-		return "", err
-	}
-
-	encodedAuth, err := encodedAuth(ref, configFile)
-	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	platform := service.Platform
@@ -199,32 +218,143 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 		platform = defaultPlatform
 	}
 
-	stream, err := s.apiClient().ImagePull(ctx, service.Image, image.PullOptions{
-		RegistryAuth: encodedAuth,
-		Platform:     platform,
-	})
+	var (
+		pulled     = service.Image
+		originErr  error
+		mirrorErrs []error
+	)
+	for i, candidate := range pullCandidates(ref, service.Image, retry.Mirrors) {
+		if i > 0 {
+			w.Event(progress.Event{
+				ID:         service.Name,
+				Kind:       api.ProgressKindImage,
+				Status:     progress.Working,
+				Text:       "Pulling",
+				StatusText: fmt.Sprintf("from mirror %s", candidate),
+			})
+		}
+		err = s.pullImageWithRetry(ctx, service.Name, candidate, configFile, w, quietPull, platform, retry)
+		if err == nil {
+			pulled = candidate
+			break
+		}
+		if i == 0 {
+			originErr = err
+		} else {
+			mirrorErrs = append(mirrorErrs, fmt.Errorf("%s: %w", candidate, err))
+		}
+		if ctx.Err() != nil || !isTransientPullError(originErr) {
+			// mirrors can't fix a missing image or denied access
+			break
+		}
+	}
+	if err != nil {
+		// report why the image couldn't be pulled from its registry, rather than from the last mirror tried
+		err = originErr
+		if len(mirrorErrs) > 0 {
+			err = fmt.Errorf("%w (mirrors failed too: %w)", originErr, errors.Join(mirrorErrs...))
+		}
+	}
 
 	// check if has error and the service has a build section
 	// then the status should be warning instead of error
 	if err != nil && service.Build != nil {
 		w.Event(progress.Event{
 			ID:         service.Name,
+			Kind:       api.ProgressKindImage,
 			Status:     progress.Warning,
 			Text:       "Warning",
-			StatusText: getUnwrappedErrorMessage(err),
+			StatusText: getUnwrappedErrorMessage(originErr),
 		})
-		return "", WrapCategorisedComposeError(err, PullFailure)
+		return "", "", WrapCategorisedComposeError(err, PullFailure)
 	}
 
 	if err != nil {
 		w.Event(progress.Event{
 			ID:         service.Name,
+			Kind:       api.ProgressKindImage,
 			Status:     progress.Error,
 			Text:       "Error",
-			StatusText: getUnwrappedErrorMessage(err),
+			StatusText: getUnwrappedErrorMessage(originErr),
 		})
-		return "", WrapCategorisedComposeError(err, PullFailure)
+		return "", "", WrapCategorisedComposeError(err, PullFailure)
+	}
+
+	var mirror string
+	if pulled != service.Image {
+		if _, canonical := ref.(reference.Canonical); canonical {
+			// a digest can't be tagged, image is only known locally by the mirror reference and its ID
+			mirror = pulled
+		} else if !s.dryRun {
+			if err := s.apiClient().ImageTag(ctx, pulled, service.Image); err != nil {
+				return "", "", err
+			}
+		}
+	}
+
+	w.Event(progress.Event{
+		ID:     service.Name,
+		Kind:   api.ProgressKindImage,
+		Status: progress.Done,
+		Text:   "Pulled",
+	})
+
+	image := service.Image
+	if mirror != "" {
+		image = mirror
 	}
+	inspected, _, err := s.apiClient().ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", "", err
+	}
+	return inspected.ID, mirror, nil
+}
+
+// pullImageWithRetry pulls an image, retrying with exponential backoff as long as it fails for a transient reason
+func (s *composeService) pullImageWithRetry(ctx context.Context, id string, img string, configFile driver.Auth,
+	w progress.Writer, quietPull bool, platform string, retry api.PullRetryPolicy) error {
+	for attempt := 1; ; attempt++ {
+		err := s.pullImage(ctx, id, img, configFile, w, quietPull, platform)
+		if err == nil || attempt >= retry.Attempts || !isTransientPullError(err) {
+			return err
+		}
+		delay := retryDelay(retry.Backoff, attempt)
+		w.Event(progress.Event{
+			ID:         id,
+			Kind:       api.ProgressKindImage,
+			Status:     progress.Working,
+			Text:       "Retrying",
+			StatusText: fmt.Sprintf("%s, attempt %d/%d in %s", getUnwrappedErrorMessage(err), attempt+1, retry.Attempts, delay.Round(time.Millisecond)),
+		})
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *composeService) pullImage(ctx context.Context, id string, img string, configFile driver.Auth, w progress.Writer, quietPull bool, platform string) error {
+	ref, err := reference.ParseNormalizedNamed(img)
+	if err != nil {
+		return err
+	}
+
+	encodedAuth, err := encodedAuth(ref, configFile)
+	if err != nil {
+		return err
+	}
+
+	stream, err := s.apiClient().ImagePull(ctx, img, image.PullOptions{
+		RegistryAuth: encodedAuth,
+		Platform:     platform,
+	})
+	if err != nil {
+		return err
+	}
+	defer stream.Close() //nolint:errcheck
 
 	dec := json.NewDecoder(stream)
 	for {
@@ -233,26 +363,90 @@ func (s *composeService) pullServiceImage(ctx context.Context, service types.Ser
 			if errors.Is(err, io.EOF) {
 				break
 			}
-			return "", WrapCategorisedComposeError(err, PullFailure)
+			return err
 		}
 		if jm.Error != nil {
-			return "", WrapCategorisedComposeError(errors.New(jm.Error.Message), PullFailure)
+			return errors.New(jm.Error.Message)
 		}
 		if !quietPull {
-			toPullProgressEvent(service.Name, jm, w)
+			toPullProgressEvent(id, jm, w)
 		}
 	}
-	w.Event(progress.Event{
-		ID:     service.Name,
-		Status: progress.Done,
-		Text:   "Pulled",
-	})
+	return nil
+}
 
-	inspected, _, err := s.apiClient().ImageInspectWithRaw(ctx, service.Image)
-	if err != nil {
-		return "", err
+const (
+	// defaultPullBackoff is the delay before retrying a failed pull, when the retry policy doesn't set one
+	defaultPullBackoff = time.Second
+	// maxPullBackoff caps the delay between pull attempts
+	maxPullBackoff = 30 * time.Second
+)
+
+// retryDelay returns the delay before the next attempt to pull an image, doubling the backoff for each attempt
+// and randomizing it between half and full value to avoid all pulls retrying at once
+func retryDelay(backoff time.Duration, attempt int) time.Duration {
+	if backoff <= 0 {
+		backoff = defaultPullBackoff
+	}
+	delay := backoff
+	for i := 1; i < attempt && delay < maxPullBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxPullBackoff)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// transientPullErrors are the messages of errors reported by the engine for a pull failure worth a retry
+var transientPullErrors = []string{
+	"connection reset",
+	"connection refused",
+	"tls handshake timeout",
+	"i/o timeout",
+	"unexpected eof",
+	"timeout exceeded while awaiting headers",
+	"toomanyrequests",
+	"429 too many requests",
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+// isTransientPullError returns true if a pull error is likely caused by network or registry hiccups
+func isTransientPullError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errdefs.IsUnavailable(err) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientPullErrors {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
+
+// pullCandidates returns the image reference followed by the equivalent references on the mirror registries
+func pullCandidates(ref reference.Named, img string, mirrors []string) []string {
+	candidates := []string{img}
+	for _, mirror := range mirrors {
+		mirrored := strings.TrimSuffix(mirror, "/") + "/" + reference.Path(ref)
+		if tagged, ok := ref.(reference.Tagged); ok {
+			mirrored += ":" + tagged.Tag()
+		}
+		if digested, ok := ref.(reference.Digested); ok {
+			mirrored += "@" + digested.Digest().String()
+		}
+		candidates = append(candidates, mirrored)
 	}
-	return inspected.ID, nil
+	return candidates
 }
 
 // ImageDigestResolver creates a func able to resolve image digest from a docker ref,
@@ -290,7 +484,25 @@ func encodedAuth(ref reference.Named, configFile driver.Auth) (string, error) {
 	return base64.URLEncoding.EncodeToString(buf), nil
 }
 
-func (s *composeService) pullRequiredImages(ctx context.Context, project *types.Project, images map[string]string, quietPull bool) error {
+func (s *composeService) pullRequiredImages(ctx context.Context, project *types.Project, images map[string]string, opts api.PullOptions) error {
+	needPull := requiredPulls(project, images)
+	if len(needPull) == 0 {
+		return nil
+	}
+
+	return progress.Run(ctx, func(ctx context.Context) error {
+		pulls := s.startPulls(ctx, project, needPull, opts)
+		err := pulls.waitAll()
+		for image, id := range pulls.ids {
+			images[image] = id
+		}
+		pulls.labelMirroredImages(project)
+		return err
+	}, s.stdinfo())
+}
+
+// requiredPulls returns the services which image must be pulled according to their pull policy
+func requiredPulls(project *types.Project, images map[string]string) []types.ServiceConfig {
 	var needPull []types.ServiceConfig
 	for _, service := range project.Services {
 		if service.Image == "" {
@@ -308,35 +520,182 @@ func (s *composeService) pullRequiredImages(ctx context.Context, project *types.
 		}
 		needPull = append(needPull, service)
 	}
-	if len(needPull) == 0 {
-		return nil
+	return needPull
+}
+
+func (s *composeService) pullConcurrency(opts api.PullOptions) int {
+	if opts.Concurrency > 0 {
+		return opts.Concurrency
 	}
+	return s.maxConcurrency
+}
 
-	return progress.Run(ctx, func(ctx context.Context) error {
-		w := progress.ContextWriter(ctx)
-		eg, ctx := errgroup.WithContext(ctx)
-		eg.SetLimit(s.maxConcurrency)
-		pulledImages := make([]string, len(needPull))
-		for i, service := range needPull {
-			i, service := i, service
+// pullOrder sorts services so that images for services started first, i.e. with the fewest levels of dependencies,
+// are pulled first
+func pullOrder(project *types.Project, services []types.ServiceConfig) []types.ServiceConfig {
+	sorted := slices.Clone(services)
+	graph, err := NewGraph(project, ServiceStopped)
+	if err != nil {
+		// invalid dependencies will be reported when services get created
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Name < sorted[j].Name
+		})
+		return sorted
+	}
+	levels := map[string]int{}
+	var level func(v *Vertex) int
+	level = func(v *Vertex) int {
+		if l, ok := levels[v.Key]; ok {
+			return l
+		}
+		l := 0
+		for _, dependency := range v.Children {
+			l = max(l, level(dependency)+1)
+		}
+		levels[v.Key] = l
+		return l
+	}
+	depth := func(service types.ServiceConfig) int {
+		if v, ok := graph.Vertices[service.Name]; ok {
+			return level(v)
+		}
+		return 0
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		di, dj := depth(sorted[i]), depth(sorted[j])
+		if di != dj {
+			return di < dj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// imagePulls tracks the images being pulled for services, so that one can wait for a service image to be available
+type imagePulls struct {
+	eg        *errgroup.Group
+	cancel    context.CancelFunc
+	scheduled chan struct{}
+	done      map[string]chan struct{}
+	mu        sync.Mutex
+	ids       map[string]string
+	errs      map[string]error
+	// mirrored are the mirror references images pinned by digest have been pulled from, by service
+	mirrored map[string]string
+}
+
+// startPulls pulls the service images in background, in dependency order and within the pull concurrency limit
+func (s *composeService) startPulls(ctx context.Context, project *types.Project, needPull []types.ServiceConfig, opts api.PullOptions) *imagePulls {
+	w := progress.ContextWriter(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(s.pullConcurrency(opts))
+	pulls := &imagePulls{
+		eg:        eg,
+		cancel:    cancel,
+		scheduled: make(chan struct{}),
+		done:      map[string]chan struct{}{},
+		ids:       map[string]string{},
+		errs:      map[string]error{},
+		mirrored:  map[string]string{},
+	}
+	ordered := pullOrder(project, needPull)
+	buildable := map[string]bool{}
+	for _, service := range ordered {
+		pulls.done[service.Name] = make(chan struct{})
+		buildable[service.Name] = isServiceImageToBuild(service, project.Services)
+	}
+	defaultPlatform := project.Environment["DOCKER_DEFAULT_PLATFORM"]
+
+	// errgroup blocks when the concurrency limit is reached, so pulls are scheduled by a distinct goroutine
+	go func() {
+		defer close(pulls.scheduled)
+		for _, service := range ordered {
+			service := service
 			eg.Go(func() error {
-				id, err := s.pullServiceImage(ctx, service, s.configFile(), w, quietPull, project.Environment["DOCKER_DEFAULT_PLATFORM"])
-				pulledImages[i] = id
-				if err != nil && isServiceImageToBuild(service, project.Services) {
-					// image can be built, so we can ignore pull failure
-					return nil
+				defer close(pulls.done[service.Name])
+				if err := ctx.Err(); err != nil {
+					pulls.failed(service.Name, err)
+					return err
+				}
+				start := time.Now()
+				id, mirror, err := s.pullServiceImage(ctx, service, s.configFile(), w, opts.Quiet, defaultPlatform, opts.Retry)
+				recordTiming(ctx, service.Name, timingPull, start)
+				if err != nil {
+					if buildable[service.Name] {
+						// image can be built, so we can ignore pull failure
+						return nil
+					}
+					pulls.failed(service.Name, err)
+					return err
+				}
+				pulls.mu.Lock()
+				pulls.ids[service.Image] = id
+				if mirror != "" {
+					pulls.mirrored[service.Name] = mirror
 				}
-				return err
+				pulls.mu.Unlock()
+				return nil
 			})
 		}
-		err := eg.Wait()
-		for i, service := range needPull {
-			if pulledImages[i] != "" {
-				images[service.Image] = pulledImages[i]
-			}
-		}
-		return err
-	}, s.stdinfo())
+	}()
+	return pulls
+}
+
+func (p *imagePulls) failed(service string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs[service] = err
+}
+
+// wait blocks until the image for a service has been pulled, and returns its ID if it was pulled
+func (p *imagePulls) wait(ctx context.Context, service types.ServiceConfig) (string, error) {
+	done, ok := p.done[service.Name]
+	if !ok {
+		return "", nil
+	}
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-done:
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.errs[service.Name]; err != nil {
+		return "", err
+	}
+	return p.ids[service.Image], nil
+}
+
+// labelMirrored records the mirror an image pinned by digest has been pulled from, so containers are created from the
+// pulled image while the service keeps its original reference, and config hash
+func (p *imagePulls) labelMirrored(service types.ServiceConfig) types.ServiceConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if mirror, ok := p.mirrored[service.Name]; ok {
+		service.CustomLabels = service.CustomLabels.Add(api.ImageMirrorLabel, mirror)
+	}
+	return service
+}
+
+// labelMirroredImages records the mirror images pinned by digest have been pulled from on the project services
+func (p *imagePulls) labelMirroredImages(project *types.Project) {
+	for name, service := range project.Services {
+		project.Services[name] = p.labelMirrored(service)
+	}
+}
+
+// waitAll blocks until all images have been pulled
+func (p *imagePulls) waitAll() error {
+	<-p.scheduled
+	defer p.cancel()
+	return p.eg.Wait()
+}
+
+// stop cancels the pulls still running and waits for them to complete
+func (p *imagePulls) stop() {
+	p.cancel()
+	_ = p.waitAll()
 }
 
 func isServiceImageToBuild(service types.ServiceConfig, services types.Services) bool {
@@ -415,6 +774,7 @@ func toPullProgressEvent(parent string, jm jsonmessage.JSONMessage, w progress.W
 
 	w.Event(progress.Event{
 		ID:         jm.ID,
+		Kind:       api.ProgressKindPullLayer,
 		ParentID:   parent,
 		Current:    current,
 		Total:      total,
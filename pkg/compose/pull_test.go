@@ -0,0 +1,267 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/config/configfile"
+	moby "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+)
+
+func TestIsTransientPullError(t *testing.T) {
+	assert.Check(t, isTransientPullError(errors.New("received unexpected HTTP status: 503 Service Unavailable")))
+	assert.Check(t, isTransientPullError(errors.New("read tcp 10.0.0.2:443: read: connection reset by peer")))
+	assert.Check(t, isTransientPullError(errors.New("net/http: TLS handshake timeout")))
+	assert.Check(t, !isTransientPullError(errors.New("manifest for nginx:nope not found: manifest unknown")))
+	assert.Check(t, !isTransientPullError(errors.New("pull access denied for private/image")))
+	assert.Check(t, !isTransientPullError(context.Canceled))
+	assert.Check(t, !isTransientPullError(errdefs.System(errors.New("failed to register layer"))))
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 10: maxPullBackoff} {
+		delay := retryDelay(time.Second, attempt)
+		assert.Check(t, delay >= expected/2 && delay <= expected, "attempt %d: %s", attempt, delay)
+	}
+	delay := retryDelay(0, 1)
+	assert.Check(t, delay >= defaultPullBackoff/2 && delay <= defaultPullBackoff)
+}
+
+func TestPullCandidates(t *testing.T) {
+	ref, err := reference.ParseNormalizedNamed("nginx:1.25")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pullCandidates(ref, "nginx:1.25", []string{"mirror.gcr.io", "registry.local:5000/hub/"}), []string{
+		"nginx:1.25",
+		"mirror.gcr.io/library/nginx:1.25",
+		"registry.local:5000/hub/library/nginx:1.25",
+	})
+
+	ref, err = reference.ParseNormalizedNamed("ghcr.io/org/app@sha256:1bb2b0d6a0a9b2c4e6e3e9b3c2c0a8b1d0d5f4a6e7c8b9a0f1e2d3c4b5a69788")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, pullCandidates(ref, ref.String(), []string{"mirror"}), []string{
+		ref.String(),
+		"mirror/org/app@sha256:1bb2b0d6a0a9b2c4e6e3e9b3c2c0a8b1d0d5f4a6e7c8b9a0f1e2d3c4b5a69788",
+	})
+}
+
+func TestPullServiceImageRetry(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	service := types.ServiceConfig{Name: "web", Image: "nginx:1.25"}
+	retry := api.PullRetryPolicy{Attempts: 3, Backoff: time.Millisecond, Mirrors: []string{"mirror.gcr.io"}}
+
+	gomock.InOrder(
+		apiClient.EXPECT().ImagePull(gomock.Any(), "nginx:1.25", gomock.Any()).
+			Return(nil, errors.New("received unexpected HTTP status: 503 Service Unavailable")),
+		apiClient.EXPECT().ImagePull(gomock.Any(), "nginx:1.25", gomock.Any()).
+			Return(io.NopCloser(strings.NewReader(`{"errorDetail":{"message":"read: connection reset by peer"}}`)), nil),
+		apiClient.EXPECT().ImagePull(gomock.Any(), "nginx:1.25", gomock.Any()).
+			Return(nil, errors.New("received unexpected HTTP status: 502 Bad Gateway")),
+		apiClient.EXPECT().ImagePull(gomock.Any(), "mirror.gcr.io/library/nginx:1.25", gomock.Any()).
+			Return(io.NopCloser(strings.NewReader(`{"status":"Pull complete"}`)), nil),
+		apiClient.EXPECT().ImageTag(gomock.Any(), "mirror.gcr.io/library/nginx:1.25", "nginx:1.25").Return(nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "nginx:1.25").Return(moby.ImageInspect{ID: "sha256:1234"}, nil, nil),
+	)
+
+	id, mirror, err := tested.pullServiceImage(context.Background(), service, configfile.New(""), progress.ContextWriter(context.Background()), true, "", retry)
+	assert.NilError(t, err)
+	assert.Equal(t, id, "sha256:1234")
+	// a tag is set on the image pulled from the mirror, so it is known by its original reference
+	assert.Equal(t, mirror, "")
+}
+
+func TestPullServiceImageDigestFromMirror(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	pinned := "nginx@sha256:1bb2b0d6a0a9b2c4e6e3e9b3c2c0a8b1d0d5f4a6e7c8b9a0f1e2d3c4b5a69788"
+	mirrored := "mirror.gcr.io/library/" + pinned
+	service := types.ServiceConfig{Name: "web", Image: pinned}
+	retry := api.PullRetryPolicy{Attempts: 1, Mirrors: []string{"mirror.gcr.io"}}
+
+	gomock.InOrder(
+		apiClient.EXPECT().ImagePull(gomock.Any(), pinned, gomock.Any()).
+			Return(nil, errors.New("received unexpected HTTP status: 503 Service Unavailable")),
+		apiClient.EXPECT().ImagePull(gomock.Any(), mirrored, gomock.Any()).
+			Return(io.NopCloser(strings.NewReader(`{"status":"Pull complete"}`)), nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), mirrored).Return(moby.ImageInspect{ID: "sha256:1234"}, nil, nil),
+	)
+
+	_, mirror, err := tested.pullServiceImage(context.Background(), service, configfile.New(""), progress.ContextWriter(context.Background()), true, "", retry)
+	assert.NilError(t, err)
+	// a digest can't be tagged, so containers have to be created from the mirror reference
+	assert.Equal(t, mirror, mirrored)
+}
+
+func TestPullServiceImageReportsOriginError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	service := types.ServiceConfig{Name: "web", Image: "nginx:1.25"}
+	retry := api.PullRetryPolicy{Attempts: 1, Mirrors: []string{"mirror.gcr.io"}}
+	apiClient.EXPECT().ImagePull(gomock.Any(), "nginx:1.25", gomock.Any()).
+		Return(nil, errors.New("received unexpected HTTP status: 503 Service Unavailable"))
+	apiClient.EXPECT().ImagePull(gomock.Any(), "mirror.gcr.io/library/nginx:1.25", gomock.Any()).
+		Return(nil, errors.New("manifest unknown"))
+
+	_, _, err := tested.pullServiceImage(context.Background(), service, configfile.New(""), progress.ContextWriter(context.Background()), true, "", retry)
+	assert.ErrorContains(t, err, "503 Service Unavailable (mirrors failed too: mirror.gcr.io/library/nginx:1.25: manifest unknown)")
+}
+
+func TestPullServiceImageNoMirrorOnPermanentError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	service := types.ServiceConfig{Name: "web", Image: "private/image:1.0"}
+	retry := api.PullRetryPolicy{Attempts: 1, Mirrors: []string{"mirror.gcr.io"}}
+	apiClient.EXPECT().ImagePull(gomock.Any(), "private/image:1.0", gomock.Any()).
+		Return(nil, errors.New("pull access denied for private/image")).Times(1)
+
+	_, _, err := tested.pullServiceImage(context.Background(), service, configfile.New(""), progress.ContextWriter(context.Background()), true, "", retry)
+	assert.Error(t, err, "pull access denied for private/image")
+}
+
+func TestImagePullsLabelMirroredImages(t *testing.T) {
+	project := &types.Project{Name: "test", Services: types.Services{
+		"web": {Name: "web", Image: "nginx@sha256:1234"},
+		"db":  {Name: "db", Image: "postgres:16"},
+	}}
+	pulls := &imagePulls{mirrored: map[string]string{"web": "mirror.gcr.io/library/nginx@sha256:1234"}}
+	pulls.labelMirroredImages(project)
+
+	// services keep their image reference, so the config hash doesn't depend on the registry images come from
+	assert.Equal(t, project.Services["web"].Image, "nginx@sha256:1234")
+	assert.Equal(t, containerImage(project, project.Services["web"]), "mirror.gcr.io/library/nginx@sha256:1234")
+	assert.Equal(t, containerImage(project, project.Services["db"]), "postgres:16")
+}
+
+func TestPullServiceImageNoRetryOnPermanentError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	service := types.ServiceConfig{Name: "web", Image: "nginx:nope"}
+	apiClient.EXPECT().ImagePull(gomock.Any(), "nginx:nope", gomock.Any()).
+		Return(nil, errors.New("manifest for nginx:nope not found: manifest unknown")).Times(1)
+
+	_, _, err := tested.pullServiceImage(context.Background(), service, configfile.New(""), progress.ContextWriter(context.Background()), true, "",
+		api.PullRetryPolicy{Attempts: 5, Backoff: time.Millisecond})
+	assert.ErrorContains(t, err, "manifest unknown")
+}
+
+func TestPullOrder(t *testing.T) {
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"ml":  {Name: "ml", Image: "ml", DependsOn: types.DependsOnConfig{"api": {Condition: types.ServiceConditionStarted, Required: true}}},
+			"api": {Name: "api", Image: "api", DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionHealthy, Required: true}}},
+			"db":  {Name: "db", Image: "postgres"},
+			"web": {Name: "web", Image: "nginx"},
+		},
+	}
+	var ordered []string
+	for _, service := range pullOrder(project, []types.ServiceConfig{project.Services["ml"], project.Services["web"], project.Services["api"], project.Services["db"]}) {
+		ordered = append(ordered, service.Name)
+	}
+	assert.DeepEqual(t, ordered, []string{"db", "web", "api", "ml"})
+}
+
+func TestStartPullsInDependencyOrder(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().ConfigFile().Return(configfile.New("")).AnyTimes()
+	tested := composeService{dockerCli: cli, maxConcurrency: 4}
+
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"ml": {Name: "ml", Image: "ml:latest", DependsOn: types.DependsOnConfig{"db": {Condition: types.ServiceConditionStarted, Required: true}}},
+			"db": {Name: "db", Image: "postgres:16"},
+		},
+	}
+	gomock.InOrder(
+		apiClient.EXPECT().ImagePull(gomock.Any(), "postgres:16", gomock.Any()).Return(io.NopCloser(strings.NewReader("")), nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "postgres:16").Return(moby.ImageInspect{ID: "sha256:db"}, nil, nil),
+		apiClient.EXPECT().ImagePull(gomock.Any(), "ml:latest", gomock.Any()).Return(io.NopCloser(strings.NewReader("")), nil),
+		apiClient.EXPECT().ImageInspectWithRaw(gomock.Any(), "ml:latest").Return(moby.ImageInspect{ID: "sha256:ml"}, nil, nil),
+	)
+
+	ctx := context.Background()
+	pulls := tested.startPulls(ctx, project, []types.ServiceConfig{project.Services["ml"], project.Services["db"]}, api.PullOptions{Quiet: true, Concurrency: 1})
+	id, err := pulls.wait(ctx, project.Services["db"])
+	assert.NilError(t, err)
+	assert.Equal(t, id, "sha256:db")
+	assert.NilError(t, pulls.waitAll())
+	id, err = pulls.wait(ctx, project.Services["ml"])
+	assert.NilError(t, err)
+	assert.Equal(t, id, "sha256:ml")
+}
+
+func TestStopPulls(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	cli.EXPECT().ConfigFile().Return(configfile.New("")).AnyTimes()
+	tested := composeService{dockerCli: cli, maxConcurrency: 4}
+
+	project := &types.Project{
+		Name: "test",
+		Services: types.Services{
+			"ml": {Name: "ml", Image: "ml:latest"},
+		},
+	}
+	started := make(chan struct{})
+	apiClient.EXPECT().ImagePull(gomock.Any(), "ml:latest", gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ image.PullOptions) (io.ReadCloser, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	pulls := tested.startPulls(context.Background(), project, []types.ServiceConfig{project.Services["ml"]}, api.PullOptions{Quiet: true})
+	<-started
+	// stop returns once the pull running in background has been canceled
+	pulls.stop()
+	_, err := pulls.wait(context.Background(), project.Services["ml"])
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -67,6 +67,7 @@ func (s *composeService) push(ctx context.Context, project *types.Project, optio
 			}
 			w.Event(progress.Event{
 				ID:     service.Name,
+				Kind:   api.ProgressKindImage,
 				Status: progress.Done,
 				Text:   "Skipped",
 			})
@@ -120,8 +121,34 @@ func (s *composeService) pushServiceImage(ctx context.Context, tag string, info
 		return err
 	}
 
+	w.Event(progress.Event{
+		ID:     tag,
+		Kind:   api.ProgressKindImage,
+		Status: progress.Working,
+		Text:   "Pushing",
+	})
+	if err := s.pushImageStream(ctx, tag, buf, w, quietPush); err != nil {
+		w.Event(progress.Event{
+			ID:         tag,
+			Kind:       api.ProgressKindImage,
+			Status:     progress.Error,
+			Text:       "Error",
+			StatusText: err.Error(),
+		})
+		return err
+	}
+	w.Event(progress.Event{
+		ID:     tag,
+		Kind:   api.ProgressKindImage,
+		Status: progress.Done,
+		Text:   "Pushed",
+	})
+	return nil
+}
+
+func (s *composeService) pushImageStream(ctx context.Context, tag string, authConfig []byte, w progress.Writer, quietPush bool) error {
 	stream, err := s.apiClient().ImagePush(ctx, tag, image.PushOptions{
-		RegistryAuth: base64.URLEncoding.EncodeToString(buf),
+		RegistryAuth: base64.URLEncoding.EncodeToString(authConfig),
 	})
 	if err != nil {
 		return err
@@ -143,11 +170,10 @@ func (s *composeService) pushServiceImage(ctx context.Context, tag string, info
 			toPushProgressEvent(tag, jm, w)
 		}
 	}
-
 	return nil
 }
 
-func toPushProgressEvent(prefix string, jm jsonmessage.JSONMessage, w progress.Writer) {
+func toPushProgressEvent(parent string, jm jsonmessage.JSONMessage, w progress.Writer) {
 	if jm.ID == "" {
 		// skipped
 		return
@@ -179,7 +205,9 @@ func toPushProgressEvent(prefix string, jm jsonmessage.JSONMessage, w progress.W
 	}
 
 	w.Event(progress.Event{
-		ID:         fmt.Sprintf("Pushing %s: %s", prefix, jm.ID),
+		ID:         jm.ID,
+		Kind:       api.ProgressKindPushLayer,
+		ParentID:   parent,
 		Text:       jm.Status,
 		Status:     status,
 		Current:    current,
@@ -105,13 +105,13 @@ func (s *composeService) remove(ctx context.Context, containers Containers, opti
 		container := container
 		eg.Go(func() error {
 			eventName := getContainerProgressName(container)
-			w.Event(progress.RemovingEvent(eventName))
+			w.Event(progress.RemovingEvent(eventName).WithKind(api.ProgressKindContainerState))
 			err := s.apiClient().ContainerRemove(ctx, container.ID, containerType.RemoveOptions{
 				RemoveVolumes: options.Volumes,
 				Force:         options.Force,
 			})
 			if err == nil {
-				w.Event(progress.RemovedEvent(eventName))
+				w.Event(progress.RemovedEvent(eventName).WithKind(api.ProgressKindContainerState))
 			}
 			return err
 		})
@@ -82,11 +82,11 @@ func (s *composeService) restart(ctx context.Context, projectName string, option
 			container := container
 			eg.Go(func() error {
 				eventName := getContainerProgressName(container)
-				w.Event(progress.RestartingEvent(eventName))
+				w.Event(progress.RestartingEvent(eventName).WithKind(api.ProgressKindContainerState))
 				timeout := utils.DurationSecondToInt(options.Timeout)
 				err := s.apiClient().ContainerRestart(ctx, container.ID, containerType.StopOptions{Timeout: timeout})
 				if err == nil {
-					w.Event(progress.StartedEvent(eventName))
+					w.Event(progress.StartedEvent(eventName).WithKind(api.ProgressKindContainerState))
 				}
 				return err
 			})
@@ -65,6 +65,12 @@ func (s *composeService) prepareRun(ctx context.Context, project *types.Project,
 
 	applyRunOptions(project, &service, opts)
 
+	if opts.GC {
+		if err := s.removeStaleOneOffContainers(ctx, project.Name); err != nil {
+			return "", err
+		}
+	}
+
 	if err := s.stdin().CheckTty(opts.Interactive, service.Tty); err != nil {
 		return "", err
 	}
@@ -83,7 +89,10 @@ func (s *composeService) prepareRun(ctx context.Context, project *types.Project,
 		Add(api.SlugLabel, slug).
 		Add(api.OneoffLabel, "True")
 
-	if err := s.ensureImagesExists(ctx, project, opts.Build, opts.QuietPull); err != nil { // all dependencies already checked, but might miss service img
+	if err := s.ensureImagesExists(ctx, project, opts.Build, api.PullOptions{
+		Quiet: opts.QuietPull,
+		Retry: opts.PullRetry,
+	}); err != nil { // all dependencies already checked, but might miss service img
 		return "", err
 	}
 
@@ -0,0 +1,225 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/compose-spec/compose-go/v2/types"
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/mattn/go-shellwords"
+)
+
+const (
+	// secretsKeyFileEnv is the project variable set with the age key file to decrypt encrypted-file secrets with,
+	// unless the provider sets a key_file
+	secretsKeyFileEnv = "COMPOSE_SECRETS_KEY_FILE"
+
+	commandSecretProvider       = "command"
+	encryptedFileSecretProvider = "encrypted-file"
+)
+
+// secretProvider resolves the value of a secret from a source Compose doesn't manage, so it never has to be stored
+// in plain text in the project files
+type secretProvider interface {
+	Resolve(ctx context.Context, project *types.Project, secret types.SecretConfig) (string, error)
+}
+
+// secretProviderConfig is the `x-provider` secret extension, set either as a map or as a `<type>:<source>` string:
+//
+//	x-provider: "command:pass show db"
+//	x-provider:
+//	  type: encrypted-file
+//	  file: ./db.age
+//	  key_file: ~/.config/compose/key.txt
+type secretProviderConfig struct {
+	Type    string
+	Command any
+	File    string
+	KeyFile string `mapstructure:"key_file"`
+}
+
+// getSecretProvider returns the provider a secret is read from, nil if the secret is sourced from a file or the
+// environment
+func getSecretProvider(secret types.SecretConfig) (secretProvider, error) {
+	value, ok := secret.Extensions[api.SecretProviderExtension]
+	if !ok {
+		return nil, nil
+	}
+	var config secretProviderConfig
+	if uri, ok := value.(string); ok {
+		kind, source, ok := strings.Cut(uri, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid %s for secret %q: %q has no provider type", api.SecretProviderExtension, secret.Name, uri)
+		}
+		config.Type = kind
+		if kind == encryptedFileSecretProvider {
+			config.File = source
+		} else {
+			config.Command = source
+		}
+	} else if _, err := secret.Extensions.Get(api.SecretProviderExtension, &config); err != nil {
+		return nil, fmt.Errorf("invalid %s for secret %q: %w", api.SecretProviderExtension, secret.Name, err)
+	}
+
+	switch config.Type {
+	case commandSecretProvider:
+		command, err := parseCommand(config.Command)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s for secret %q: %w", api.SecretProviderExtension, secret.Name, err)
+		}
+		return commandProvider{command: command}, nil
+	case encryptedFileSecretProvider:
+		return encryptedFileProvider{file: config.File, keyFile: config.KeyFile}, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s type %q for secret %q", api.SecretProviderExtension, config.Type, secret.Name)
+	}
+}
+
+// parseCommand parses a command set either as a string, parsed as a shell command line, or as a list
+func parseCommand(value any) ([]string, error) {
+	var command []string
+	switch v := value.(type) {
+	case string:
+		parsed, err := shellwords.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		command = parsed
+	case []any:
+		for _, arg := range v {
+			command = append(command, fmt.Sprint(arg))
+		}
+	case []string:
+		command = v
+	}
+	if len(command) == 0 {
+		return nil, errors.New("command is required")
+	}
+	return command, nil
+}
+
+// commandProvider runs a command on the host, which output is the secret value. This makes password managers and
+// vault CLIs usable as secret sources, like `pass show`, `op read` or `vault kv get -field`
+type commandProvider struct {
+	command []string
+}
+
+func (p commandProvider) Resolve(ctx context.Context, project *types.Project, secret types.SecretConfig) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Dir = project.WorkingDir
+	cmd.Env = append(os.Environ(), project.Environment.Values()...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to read secret %q with %q: %w: %s", secret.Name, p.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	// commands print values followed by a newline, which isn't part of the secret
+	value := strings.TrimSuffix(stdout.String(), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// encryptedFileProvider decrypts a secret stored in a local file encrypted with age (https://age-encryption.org), so
+// it can be committed along with the project. The file is encrypted for one or more recipients with `age -r`, binary
+// or armored, and decrypted with the identities of the key file, as generated by `age-keygen`
+type encryptedFileProvider struct {
+	file    string
+	keyFile string
+}
+
+func (p encryptedFileProvider) Resolve(_ context.Context, project *types.Project, secret types.SecretConfig) (string, error) {
+	file := p.file
+	if file == "" {
+		file = secret.File
+	}
+	if file == "" {
+		return "", fmt.Errorf("secret %q has no encrypted file", secret.Name)
+	}
+	keyFile := p.keyFile
+	if keyFile == "" {
+		keyFile = project.Environment[secretsKeyFileEnv]
+	}
+	if keyFile == "" {
+		return "", fmt.Errorf("secret %q is encrypted, set a key_file or %s to decrypt it", secret.Name, secretsKeyFileEnv)
+	}
+
+	identities, err := readIdentities(project, keyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key for secret %q: %w", secret.Name, err)
+	}
+	path, err := projectPath(project, file)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", secret.Name, err)
+	}
+	var encrypted io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) {
+		encrypted = armor.NewReader(encrypted)
+	}
+	decrypted, err := age.Decrypt(encrypted, identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %q: %w", secret.Name, err)
+	}
+	value, err := io.ReadAll(decrypted)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret %q: %w", secret.Name, err)
+	}
+	return string(value), nil
+}
+
+// readIdentities reads the age identities of a key file
+func readIdentities(project *types.Project, keyFile string) ([]age.Identity, error) {
+	path, err := projectPath(project, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+	return age.ParseIdentities(f)
+}
+
+// projectPath returns the path of a file relative to the project working directory, or to the user home directory
+func projectPath(project *types.Project, path string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(project.WorkingDir, path)
+	}
+	return path, nil
+}
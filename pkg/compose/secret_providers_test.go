@@ -0,0 +1,158 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/compose-spec/compose-go/v2/types"
+	"gotest.tools/v3/assert"
+)
+
+func TestGetSecretProvider(t *testing.T) {
+	provider, err := getSecretProvider(types.SecretConfig{Name: "db", Environment: "DB"})
+	assert.NilError(t, err)
+	assert.Check(t, provider == nil)
+
+	provider, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{
+		"x-provider": "command:pass show 'db password'",
+	}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, provider.(commandProvider).command, []string{"pass", "show", "db password"})
+
+	provider, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{
+		"x-provider": map[string]any{"type": "command", "command": []any{"op", "read", "op://vault/db/password"}},
+	}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, provider.(commandProvider).command, []string{"op", "read", "op://vault/db/password"})
+
+	provider, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{
+		"x-provider": "encrypted-file:./db.enc",
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, provider, secretProvider(encryptedFileProvider{file: "./db.enc"}))
+
+	provider, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{
+		"x-provider": map[string]any{"type": "encrypted-file", "file": "./db.enc", "key_file": "./key"},
+	}})
+	assert.NilError(t, err)
+	assert.Equal(t, provider, secretProvider(encryptedFileProvider{file: "./db.enc", keyFile: "./key"}))
+
+	_, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{"x-provider": "pass"}})
+	assert.ErrorContains(t, err, "has no provider type")
+	_, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{"x-provider": "vault:db"}})
+	assert.ErrorContains(t, err, `unsupported x-provider type "vault"`)
+	_, err = getSecretProvider(types.SecretConfig{Name: "db", Extensions: types.Extensions{"x-provider": "command:"}})
+	assert.ErrorContains(t, err, "command is required")
+}
+
+func TestCommandProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	project := &types.Project{
+		WorkingDir:  t.TempDir(),
+		Environment: types.Mapping{"DB_USER": "admin"},
+	}
+	value, err := commandProvider{command: []string{"sh", "-c", `echo "$DB_USER:s3cr3t"`}}.
+		Resolve(context.Background(), project, types.SecretConfig{Name: "db"})
+	assert.NilError(t, err)
+	assert.Equal(t, value, "admin:s3cr3t")
+
+	_, err = commandProvider{command: []string{"sh", "-c", "echo locked >&2; exit 1"}}.
+		Resolve(context.Background(), project, types.SecretConfig{Name: "db"})
+	assert.ErrorContains(t, err, `failed to read secret "db" with "sh": exit status 1: locked`)
+}
+
+func TestEncryptedFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	identity, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+	var encrypted bytes.Buffer
+	armored := armor.NewWriter(&encrypted)
+	w, err := age.Encrypt(armored, identity.Recipient())
+	assert.NilError(t, err)
+	_, err = w.Write([]byte("s3cr3t"))
+	assert.NilError(t, err)
+	assert.NilError(t, w.Close())
+	assert.NilError(t, armored.Close())
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "key.txt"), []byte(identity.String()+"\n"), 0o600))
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "db.age"), encrypted.Bytes(), 0o600))
+
+	project := &types.Project{
+		WorkingDir:  dir,
+		Environment: types.Mapping{secretsKeyFileEnv: "key.txt"},
+	}
+	secret := types.SecretConfig{Name: "db", Extensions: types.Extensions{"x-provider": "encrypted-file:db.age"}}
+	provider, err := getSecretProvider(secret)
+	assert.NilError(t, err)
+	value, err := provider.Resolve(context.Background(), project, secret)
+	assert.NilError(t, err)
+	assert.Equal(t, value, "s3cr3t")
+
+	_, err = encryptedFileProvider{}.Resolve(context.Background(), &types.Project{WorkingDir: dir},
+		types.SecretConfig{Name: "db", File: filepath.Join(dir, "db.age")})
+	assert.ErrorContains(t, err, "set a key_file or COMPOSE_SECRETS_KEY_FILE")
+
+	other, err := age.GenerateX25519Identity()
+	assert.NilError(t, err)
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte(other.String()), 0o600))
+	_, err = encryptedFileProvider{file: "db.age", keyFile: "other.txt"}.Resolve(context.Background(), project, secret)
+	assert.ErrorContains(t, err, `failed to decrypt secret "db"`)
+}
+
+func TestSecretFilesFromProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	project := &types.Project{
+		WorkingDir: t.TempDir(),
+		Services: types.Services{
+			"web": {
+				Name:    "web",
+				Secrets: []types.ServiceSecretConfig{{Source: "db"}, {Source: "cert"}},
+			},
+		},
+		Secrets: types.Secrets{
+			"db":   {Name: "db", Extensions: types.Extensions{"x-provider": "command:echo s3cr3t"}},
+			"cert": {Name: "cert", File: "/certs/cert.pem"},
+		},
+	}
+	tested := composeService{}
+	_, err := tested.secretFiles(context.Background(), project, project.Services["web"])
+	assert.ErrorContains(t, err, `secret "db" runs a command on the host`)
+
+	tested.hostCommands = true
+	files, err := tested.secretFiles(context.Background(), project, project.Services["web"])
+	assert.NilError(t, err)
+	assert.Equal(t, len(files), 1)
+	assert.Equal(t, files[0].content, "s3cr3t")
+	assert.Equal(t, files[0].config.Target, "/run/secrets/db")
+
+	mounts, err := buildContainerSecretMounts(*project, project.Services["web"])
+	assert.NilError(t, err)
+	assert.Equal(t, len(mounts), 1)
+	assert.Equal(t, mounts[0].Target, "/run/secrets/cert")
+}
@@ -28,10 +28,49 @@ import (
 	"github.com/docker/docker/api/types/container"
 )
 
+// injectedFile is the content of a secret or config sourced from the environment, a provider or set inline, which is
+// copied into containers as they can't be bind mounted
+type injectedFile struct {
+	content string
+	config  types.FileReferenceConfig
+}
+
 func (s *composeService) injectSecrets(ctx context.Context, project *types.Project, service types.ServiceConfig, id string) error {
+	files, err := s.secretFiles(ctx, project, service)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.copyInjectedFile(ctx, id, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *composeService) injectConfigs(ctx context.Context, project *types.Project, service types.ServiceConfig, id string) error {
+	files, err := configFiles(project, service)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := s.copyInjectedFile(ctx, id, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretFiles returns the secrets of a service which are sourced from the environment or a provider
+func (s *composeService) secretFiles(ctx context.Context, project *types.Project, service types.ServiceConfig) ([]injectedFile, error) {
+	var files []injectedFile
 	for _, config := range service.Secrets {
 		file := project.Secrets[config.Source]
-		if file.Environment == "" {
+		provider, err := getSecretProvider(file)
+		if err != nil {
+			return nil, err
+		}
+		if provider == nil && file.Environment == "" {
 			continue
 		}
 
@@ -41,33 +80,39 @@ func (s *composeService) injectSecrets(ctx context.Context, project *types.Proje
 			config.Target = "/run/secrets/" + config.Target
 		}
 
-		env, ok := project.Environment[file.Environment]
-		if !ok {
-			return fmt.Errorf("environment variable %q required by file %q is not set", file.Environment, file.Name)
-		}
-		b, err := createTar(env, types.FileReferenceConfig(config))
-		if err != nil {
-			return err
+		if provider != nil {
+			if _, ok := provider.(commandProvider); ok {
+				if err := s.checkHostCommands(project, fmt.Sprintf("secret %q", file.Name)); err != nil {
+					return nil, err
+				}
+			}
+			value, err := provider.Resolve(ctx, project, file)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, injectedFile{content: value, config: types.FileReferenceConfig(config)})
+			continue
 		}
 
-		err = s.apiClient().CopyToContainer(ctx, id, "/", &b, container.CopyToContainerOptions{
-			CopyUIDGID: config.UID != "" || config.GID != "",
-		})
-		if err != nil {
-			return err
+		env, ok := project.Environment[file.Environment]
+		if !ok {
+			return nil, fmt.Errorf("environment variable %q required by file %q is not set", file.Environment, file.Name)
 		}
+		files = append(files, injectedFile{content: env, config: types.FileReferenceConfig(config)})
 	}
-	return nil
+	return files, nil
 }
 
-func (s *composeService) injectConfigs(ctx context.Context, project *types.Project, service types.ServiceConfig, id string) error {
+// configFiles returns the configs of a service which are sourced from the environment or set inline
+func configFiles(project *types.Project, service types.ServiceConfig) ([]injectedFile, error) {
+	var files []injectedFile
 	for _, config := range service.Configs {
 		file := project.Configs[config.Source]
 		content := file.Content
 		if file.Environment != "" {
 			env, ok := project.Environment[file.Environment]
 			if !ok {
-				return fmt.Errorf("environment variable %q required by file %q is not set", file.Environment, file.Name)
+				return nil, fmt.Errorf("environment variable %q required by file %q is not set", file.Environment, file.Name)
 			}
 			content = env
 		}
@@ -78,20 +123,20 @@ func (s *composeService) injectConfigs(ctx context.Context, project *types.Proje
 		if config.Target == "" {
 			config.Target = "/" + config.Source
 		}
+		files = append(files, injectedFile{content: content, config: types.FileReferenceConfig(config)})
+	}
+	return files, nil
+}
 
-		b, err := createTar(content, types.FileReferenceConfig(config))
-		if err != nil {
-			return err
-		}
-
-		err = s.apiClient().CopyToContainer(ctx, id, "/", &b, container.CopyToContainerOptions{
-			CopyUIDGID: config.UID != "" || config.GID != "",
-		})
-		if err != nil {
-			return err
-		}
+func (s *composeService) copyInjectedFile(ctx context.Context, id string, file injectedFile) error {
+	b, err := createTar(file.content, file.config)
+	if err != nil {
+		return err
 	}
-	return nil
+
+	return s.apiClient().CopyToContainer(ctx, id, "/", &b, container.CopyToContainerOptions{
+		CopyUIDGID: file.config.UID != "" || file.config.GID != "",
+	})
 }
 
 func createTar(env string, config types.FileReferenceConfig) (bytes.Buffer, error) {
@@ -0,0 +1,172 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	moby "github.com/docker/docker/api/types"
+	"github.com/moby/sys/signal"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/docker/compose/v2/pkg/api"
+	"github.com/docker/compose/v2/pkg/progress"
+)
+
+// reloadConfig is the `x-reload` service extension, telling how a service reloads its secrets and configs once
+// updated: by running a command in the container, or sending it a signal
+type reloadConfig struct {
+	Command []string
+	User    string
+	Signal  string
+}
+
+func (s *composeService) RefreshSecrets(ctx context.Context, project *types.Project, options api.RefreshSecretsOptions) error {
+	return progress.RunWithTitle(ctx, func(ctx context.Context) error {
+		return s.refreshSecrets(ctx, project, options)
+	}, s.stdinfo(), "Refreshing")
+}
+
+func (s *composeService) refreshSecrets(ctx context.Context, project *types.Project, options api.RefreshSecretsOptions) error {
+	reloads, err := getReloadConfigs(project)
+	if err != nil {
+		return err
+	}
+	containers, err := s.getContainers(ctx, project.Name, oneOffExclude, false, options.Services...)
+	if err != nil {
+		return err
+	}
+	w := progress.ContextWriter(ctx)
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, ctr := range containers {
+		service, err := project.GetService(ctr.Labels[api.ServiceLabel])
+		if err != nil {
+			// orphaned container
+			continue
+		}
+		eg.Go(func() error {
+			return s.refreshContainerSecrets(ctx, w, project, service, ctr, reloads[service.Name], options.NoReload)
+		})
+	}
+	return eg.Wait()
+}
+
+// refreshContainerSecrets copies the secrets and configs which content changed into a running container, then
+// reloads it
+func (s *composeService) refreshContainerSecrets(ctx context.Context, w progress.Writer, project *types.Project, service types.ServiceConfig, ctr moby.Container, reload *reloadConfig, noReload bool) error {
+	secrets, err := s.secretFiles(ctx, project, service)
+	if err != nil {
+		return err
+	}
+	configs, err := configFiles(project, service)
+	if err != nil {
+		return err
+	}
+	files := append(secrets, configs...)
+	if len(files) == 0 {
+		return nil
+	}
+
+	eventName := getContainerProgressName(ctr)
+	var updated int
+	for _, file := range files {
+		current, err := s.readContainerFile(ctx, ctr.ID, file.config.Target)
+		if err == nil && current == file.content {
+			continue
+		}
+		w.Event(progress.Event{ID: eventName, Kind: api.ProgressKindContainerState, Status: progress.Working, StatusText: "Updating " + file.config.Target})
+		if err := s.copyInjectedFile(ctx, ctr.ID, file); err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, err.Error()).WithKind(api.ProgressKindContainerState))
+			return err
+		}
+		updated++
+	}
+	if updated == 0 {
+		w.Event(progress.Event{ID: eventName, Kind: api.ProgressKindContainerState, Status: progress.Done, StatusText: "Up to date"})
+		return nil
+	}
+
+	status := fmt.Sprintf("Updated %d files", updated)
+	if !noReload && reload != nil {
+		if err := s.reloadContainer(ctx, service, ctr, *reload); err != nil {
+			w.Event(progress.ErrorMessageEvent(eventName, err.Error()).WithKind(api.ProgressKindContainerState))
+			return err
+		}
+		status += ", reloaded"
+	}
+	w.Event(progress.Event{ID: eventName, Kind: api.ProgressKindContainerState, Status: progress.Done, StatusText: status})
+	return nil
+}
+
+// readContainerFile returns the content of a file in a container
+func (s *composeService) readContainerFile(ctx context.Context, id string, path string) (string, error) {
+	content, _, err := s.apiClient().CopyFromContainer(ctx, id, path)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close() //nolint:errcheck
+	tr := tar.NewReader(content)
+	if _, err := tr.Next(); err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(tr)
+	return string(b), err
+}
+
+// getReloadConfigs returns the reload actions declared by the services of a project with `x-reload`, checking
+// they can be run before any container is updated
+func getReloadConfigs(project *types.Project) (map[string]*reloadConfig, error) {
+	reloads := map[string]*reloadConfig{}
+	for name, service := range project.Services {
+		var reload reloadConfig
+		ok, err := service.Extensions.Get("x-reload", &reload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x-reload for service %q: %w", name, err)
+		}
+		if !ok {
+			continue
+		}
+		if len(reload.Command) == 0 && reload.Signal == "" {
+			return nil, fmt.Errorf("invalid x-reload for service %q: either command or signal must be set", name)
+		}
+		if reload.Signal != "" {
+			if _, err := signal.ParseSignal(reload.Signal); err != nil {
+				return nil, fmt.Errorf("invalid x-reload for service %q: %w", name, err)
+			}
+		}
+		reloads[name] = &reload
+	}
+	return reloads, nil
+}
+
+// reloadContainer runs the reload action declared by the service with `x-reload`
+func (s *composeService) reloadContainer(ctx context.Context, service types.ServiceConfig, ctr moby.Container, reload reloadConfig) error {
+	if len(reload.Command) > 0 {
+		err := s.execHook(ctx, ctr, service, types.ServiceHook{Command: reload.Command, User: reload.User}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	if reload.Signal != "" {
+		return s.apiClient().ContainerKill(ctx, ctr.ID, reload.Signal)
+	}
+	return nil
+}
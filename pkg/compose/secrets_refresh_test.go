@@ -0,0 +1,101 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	moby "github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	compose "github.com/docker/compose/v2/pkg/api"
+)
+
+func TestRefreshSecrets(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	api, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	project := &types.Project{
+		Name: strings.ToLower(testProject),
+		Services: types.Services{
+			"web": {
+				Name:       "web",
+				Secrets:    []types.ServiceSecretConfig{{Source: "cert"}},
+				Configs:    []types.ServiceConfigObjConfig{{Source: "site", Target: "/etc/site.conf"}},
+				Extensions: types.Extensions{"x-reload": map[string]any{"signal": "SIGHUP"}},
+			},
+		},
+		Secrets:     types.Secrets{"cert": {Name: "cert", Environment: "CERT"}},
+		Configs:     types.Configs{"site": {Name: "site", Content: "listen 80"}},
+		Environment: types.Mapping{"CERT": "new certificate"},
+	}
+
+	web := testContainer("web", "123", false)
+	listOptions := projectFilterListOpt(false)
+	listOptions.All = false
+	api.EXPECT().ContainerList(gomock.Any(), listOptions).Return([]moby.Container{web}, nil)
+	current, err := createTar("old certificate", types.FileReferenceConfig{Target: "cert"})
+	assert.NilError(t, err)
+	api.EXPECT().CopyFromContainer(gomock.Any(), "123", "/run/secrets/cert").
+		Return(io.NopCloser(&current), containerType.PathStat{}, nil)
+	site, err := createTar("listen 80", types.FileReferenceConfig{Target: "site.conf"})
+	assert.NilError(t, err)
+	api.EXPECT().CopyFromContainer(gomock.Any(), "123", "/etc/site.conf").
+		Return(io.NopCloser(&site), containerType.PathStat{}, nil)
+	api.EXPECT().CopyToContainer(gomock.Any(), "123", "/", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	api.EXPECT().ContainerKill(gomock.Any(), "123", "SIGHUP").Return(nil)
+
+	err = tested.refreshSecrets(context.Background(), project, compose.RefreshSecretsOptions{})
+	assert.NilError(t, err)
+}
+
+func TestGetReloadConfigs(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"web": {
+				Name:       "web",
+				Extensions: types.Extensions{"x-reload": map[string]any{"signal": "SIGHUP"}},
+			},
+			"db": {Name: "db"},
+		},
+	}
+	reloads, err := getReloadConfigs(project)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reloads, map[string]*reloadConfig{"web": {Signal: "SIGHUP"}})
+
+	project.Services["db"] = types.ServiceConfig{
+		Name:       "db",
+		Extensions: types.Extensions{"x-reload": map[string]any{"signal": "SIGNOPE"}},
+	}
+	_, err = getReloadConfigs(project)
+	assert.ErrorContains(t, err, `invalid x-reload for service "db"`)
+
+	project.Services["db"] = types.ServiceConfig{
+		Name:       "db",
+		Extensions: types.Extensions{"x-reload": map[string]any{"user": "root"}},
+	}
+	_, err = getReloadConfigs(project)
+	assert.ErrorContains(t, err, "either command or signal must be set")
+}
@@ -43,6 +43,12 @@ func (s *composeService) Start(ctx context.Context, projectName string, options
 }
 
 func (s *composeService) start(ctx context.Context, projectName string, options api.StartOptions, listener api.ContainerEventListener) error {
+	return s.startAndThen(ctx, projectName, options, listener, nil)
+}
+
+// startAndThen starts the project services, then runs onStarted once they are all started, or healthy when waiting
+// for them, while the containers the listener is attached to are still being watched
+func (s *composeService) startAndThen(ctx context.Context, projectName string, options api.StartOptions, listener api.ContainerEventListener, onStarted func(context.Context) error) error {
 	project := options.Project
 	if project == nil {
 		var containers Containers
@@ -143,21 +149,27 @@ func (s *composeService) start(ctx context.Context, projectName string, options
 				Required:  true,
 			}
 		}
+		waitCtx := ctx
 		if options.WaitTimeout > 0 {
 			withTimeout, cancel := context.WithTimeout(ctx, options.WaitTimeout)
-			ctx = withTimeout
+			waitCtx = withTimeout
 			defer cancel()
 		}
 
-		err = s.waitDependencies(ctx, project, project.Name, depends, containers, 0)
+		err = s.waitDependencies(waitCtx, project, project.Name, depends, containers, 0)
 		if err != nil {
-			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			if errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
 				return fmt.Errorf("application not healthy after %s", options.WaitTimeout)
 			}
 			return err
 		}
 	}
 
+	if onStarted != nil {
+		if err := onStarted(ctx); err != nil {
+			return err
+		}
+	}
 	return eg.Wait()
 }
 
@@ -0,0 +1,296 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+)
+
+const (
+	timingPull    = "pull"
+	timingBuild   = "build"
+	timingCreate  = "create"
+	timingWait    = "wait"
+	timingStart   = "start"
+	timingHealthy = "healthy"
+
+	// waterfallWidth is the number of columns the waterfall spreads the whole `up` duration over
+	waterfallWidth = 40
+)
+
+// timingPhases are the phases of a service startup, in the order they happen
+var timingPhases = []string{timingPull, timingBuild, timingCreate, timingWait, timingStart, timingHealthy}
+
+// timingSpan is the time range a phase took for a service. When a service has multiple containers, the span covers
+// all of them
+type timingSpan struct {
+	start time.Time
+	end   time.Time
+}
+
+// timings records the time spent by each service in each phase of `up`
+type timings struct {
+	mtx      sync.Mutex
+	start    time.Time
+	services map[string]map[string]timingSpan
+	// watchers are the services being watched to record when they become healthy
+	watchers sync.WaitGroup
+}
+
+type timingsKey struct{}
+
+func newTimings() *timings {
+	return &timings{
+		start:    time.Now(),
+		services: map[string]map[string]timingSpan{},
+	}
+}
+
+// withTimings returns a context recording the phase timings of the services into t
+func withTimings(ctx context.Context, t *timings) context.Context {
+	return context.WithValue(ctx, timingsKey{}, t)
+}
+
+// recordTiming records a service spent time in a phase since start, if timings are recorded for the context
+func recordTiming(ctx context.Context, service, phase string, start time.Time) {
+	if t, ok := ctx.Value(timingsKey{}).(*timings); ok {
+		t.record(service, phase, start, time.Now())
+	}
+}
+
+// recordHealthy records a service became healthy, or completed, since its containers were started
+func recordHealthy(ctx context.Context, service string) {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return
+	}
+	t.mtx.Lock()
+	started, ok := t.services[service][timingStart]
+	_, healthy := t.services[service][timingHealthy]
+	t.mtx.Unlock()
+	if !ok || healthy {
+		// the service was already running, or another dependent already saw it healthy
+		return
+	}
+	t.record(service, timingHealthy, started.end, time.Now())
+}
+
+// watchHealthy records when the containers of a service, just started, become healthy. Dependents only wait for
+// the services they depend on, so services no one depends on are watched from their own start
+func (s *composeService) watchHealthy(ctx context.Context, service string, containers Containers) {
+	t, ok := ctx.Value(timingsKey{}).(*timings)
+	if !ok {
+		return
+	}
+	// the start context is canceled once all services are started, before they may be healthy
+	ctx = context.WithoutCancel(ctx)
+	t.watchers.Add(1)
+	go func() {
+		defer t.watchers.Done()
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for range ticker.C {
+			healthy, err := s.isServiceHealthy(ctx, containers, false)
+			if err != nil {
+				// the service has no healthcheck, or failed to become healthy
+				return
+			}
+			if healthy {
+				recordHealthy(ctx, service)
+				return
+			}
+		}
+	}()
+}
+
+// waitHealthy waits for the watched services to become healthy or fail to, unless ctx is done first
+func (t *timings) waitHealthy(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		t.watchers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+func (t *timings) record(service, phase string, start, end time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	phases, ok := t.services[service]
+	if !ok {
+		phases = map[string]timingSpan{}
+		t.services[service] = phases
+	}
+	span, ok := phases[phase]
+	if !ok {
+		phases[phase] = timingSpan{start: start, end: end}
+		return
+	}
+	if start.Before(span.start) {
+		span.start = start
+	}
+	if end.After(span.end) {
+		span.end = end
+	}
+	phases[phase] = span
+}
+
+// finished returns the time a service completed its last phase
+func (t *timings) finished(service string) time.Time {
+	var end time.Time
+	for _, span := range t.services[service] {
+		if span.end.After(end) {
+			end = span.end
+		}
+	}
+	return end
+}
+
+// started returns the time a service started its first phase
+func (t *timings) started(service string) time.Time {
+	var start time.Time
+	for _, span := range t.services[service] {
+		if start.IsZero() || span.start.Before(start) {
+			start = span.start
+		}
+	}
+	return start
+}
+
+// criticalPath returns the chain of dependencies which delayed the most the last service to be ready: starting from
+// that service, it follows the dependency which was ready the latest, down to a service without dependencies
+func (t *timings) criticalPath(project *types.Project) []string {
+	var last string
+	for name := range t.services {
+		if last == "" || t.finished(name).After(t.finished(last)) || (t.finished(name).Equal(t.finished(last)) && name < last) {
+			last = name
+		}
+	}
+	var path []string
+	for name := last; name != ""; {
+		path = append(path, name)
+		service, ok := project.Services[name]
+		name = ""
+		if !ok {
+			break
+		}
+		for dependency := range service.DependsOn {
+			if _, recorded := t.services[dependency]; !recorded || slices.Contains(path, dependency) {
+				continue
+			}
+			if name == "" || t.finished(dependency).After(t.finished(name)) || (t.finished(dependency).Equal(t.finished(name)) && dependency < name) {
+				name = dependency
+			}
+		}
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// write prints a table with the duration of each phase per service, a waterfall of the phases over the `up`
+// duration, and the critical path through the dependency graph
+func (t *timings) write(out io.Writer, project *types.Project) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if len(t.services) == 0 {
+		return nil
+	}
+
+	services := make([]string, 0, len(t.services))
+	var end time.Time
+	for name := range t.services {
+		services = append(services, name)
+		if finished := t.finished(name); finished.After(end) {
+			end = finished
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		si, sj := t.started(services[i]), t.started(services[j])
+		if si.Equal(sj) {
+			return services[i] < services[j]
+		}
+		return si.Before(sj)
+	})
+	total := end.Sub(t.start)
+	path := t.criticalPath(project)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Timings (total %s)\n", formatTiming(total))
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "SERVICE\t%s\tWATERFALL\n", strings.ToUpper(strings.Join(timingPhases, "\t")))
+	for _, name := range services {
+		if slices.Contains(path, name) {
+			fmt.Fprintf(tw, "%s *", name)
+		} else {
+			fmt.Fprint(tw, name)
+		}
+		phases := t.services[name]
+		for _, phase := range timingPhases {
+			if span, ok := phases[phase]; ok {
+				fmt.Fprintf(tw, "\t%s", formatTiming(span.end.Sub(span.start)))
+			} else {
+				fmt.Fprint(tw, "\t-")
+			}
+		}
+		fmt.Fprintf(tw, "\t|%s|\n", t.waterfall(phases, total))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	b.WriteString("Waterfall: P pull, B build, C create, W waiting for dependencies, S start, H healthy\n")
+	fmt.Fprintf(&b, "Critical path (*): %s (%s)\n", strings.Join(path, " → "), formatTiming(t.finished(path[len(path)-1]).Sub(t.start)))
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// waterfall renders the phases of a service as a bar, each column covering a slice of the `up` duration
+func (t *timings) waterfall(phases map[string]timingSpan, total time.Duration) string {
+	bar := []byte(strings.Repeat(" ", waterfallWidth))
+	if total <= 0 {
+		return string(bar)
+	}
+	column := func(at time.Time) int {
+		return max(0, min(waterfallWidth-1, int(at.Sub(t.start)*waterfallWidth/total)))
+	}
+	for _, phase := range timingPhases {
+		span, ok := phases[phase]
+		if !ok {
+			continue
+		}
+		for i := column(span.start); i <= column(span.end); i++ {
+			bar[i] = strings.ToUpper(phase)[0]
+		}
+	}
+	return string(bar)
+}
+
+func formatTiming(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,141 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	moby "github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func testTimings() (*timings, *types.Project) {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time {
+		return start.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	t := &timings{start: start, services: map[string]map[string]timingSpan{}}
+	t.record("db", timingPull, at(0), at(2))
+	t.record("db", timingCreate, at(2), at(2.5))
+	t.record("db", timingStart, at(2.5), at(3))
+	t.record("db", timingHealthy, at(3), at(8))
+	t.record("cache", timingCreate, at(0), at(0.5))
+	t.record("cache", timingStart, at(0.5), at(1))
+	t.record("cache", timingHealthy, at(1), at(2))
+	t.record("web", timingBuild, at(0), at(4))
+	t.record("web", timingCreate, at(4), at(4.5))
+	t.record("web", timingWait, at(4.5), at(8))
+	t.record("web", timingStart, at(8), at(10))
+
+	project := &types.Project{Services: types.Services{
+		"db":    {Name: "db"},
+		"cache": {Name: "cache"},
+		"web": {Name: "web", DependsOn: types.DependsOnConfig{
+			"db":    {Condition: types.ServiceConditionHealthy},
+			"cache": {Condition: types.ServiceConditionHealthy},
+		}},
+	}}
+	return t, project
+}
+
+func TestTimingsRecord(t *testing.T) {
+	start := time.Now()
+	recorder := &timings{start: start, services: map[string]map[string]timingSpan{}}
+	recorder.record("web", timingCreate, start.Add(time.Second), start.Add(2*time.Second))
+	recorder.record("web", timingCreate, start, start.Add(time.Second))
+	span := recorder.services["web"][timingCreate]
+	assert.Check(t, span.start.Equal(start))
+	assert.Check(t, span.end.Equal(start.Add(2*time.Second)))
+}
+
+func TestRecordHealthy(t *testing.T) {
+	recorder := newTimings()
+	ctx := withTimings(context.Background(), recorder)
+
+	recordHealthy(ctx, "db")
+	_, ok := recorder.services["db"]
+	assert.Check(t, !ok, "service which wasn't started should not be recorded healthy")
+
+	recordTiming(ctx, "db", timingStart, time.Now())
+	recordHealthy(ctx, "db")
+	first := recorder.services["db"][timingHealthy]
+	assert.Check(t, first.start.Equal(recorder.services["db"][timingStart].end))
+
+	recordHealthy(ctx, "db")
+	assert.Check(t, recorder.services["db"][timingHealthy].end.Equal(first.end))
+
+	// no timings recorded without the recorder in context
+	recordTiming(context.Background(), "web", timingStart, time.Now())
+	_, ok = recorder.services["web"]
+	assert.Check(t, !ok)
+}
+
+func TestWatchHealthy(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	apiClient, cli := prepareMocks(mockCtrl)
+	tested := composeService{dockerCli: cli}
+
+	inspect := func(status string) moby.ContainerJSON {
+		return moby.ContainerJSON{
+			ContainerJSONBase: &moby.ContainerJSONBase{
+				Name:  "/web",
+				State: &moby.ContainerState{Status: ContainerRunning, Health: &moby.Health{Status: status}},
+			},
+			Config: &containerType.Config{Healthcheck: &containerType.HealthConfig{Test: []string{"CMD", "true"}}},
+		}
+	}
+	gomock.InOrder(
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "web-id").Return(inspect(moby.Starting), nil),
+		apiClient.EXPECT().ContainerInspect(gomock.Any(), "web-id").Return(inspect(moby.Healthy), nil),
+	)
+
+	recorder := newTimings()
+	ctx, cancel := context.WithCancel(withTimings(context.Background(), recorder))
+	recordTiming(ctx, "web", timingStart, time.Now())
+	tested.watchHealthy(ctx, "web", Containers{testContainer("web", "web-id", false)})
+	// the service is still watched once the start context is canceled
+	cancel()
+	recorder.waitHealthy(context.Background())
+	_, ok := recorder.services["web"][timingHealthy]
+	assert.Check(t, ok, "service no one depends on should be recorded healthy")
+}
+
+func TestTimingsCriticalPath(t *testing.T) {
+	recorder, project := testTimings()
+	assert.DeepEqual(t, recorder.criticalPath(project), []string{"db", "web"})
+}
+
+func TestTimingsWrite(t *testing.T) {
+	recorder, project := testTimings()
+	var b bytes.Buffer
+	assert.NilError(t, recorder.write(&b, project))
+	assert.Equal(t, b.String(), `Timings (total 10.0s)
+SERVICE  PULL  BUILD  CREATE  WAIT  START  HEALTHY  WATERFALL
+cache    -     -      0.5s    -     0.5s   1.0s     |CCSSHHHHH                               |
+db *     2.0s  -      0.5s    -     0.5s   5.0s     |PPPPPPPPCCSSHHHHHHHHHHHHHHHHHHHHH       |
+web *    -     4.0s   0.5s    3.5s  2.0s   -        |BBBBBBBBBBBBBBBBCCWWWWWWWWWWWWWWSSSSSSSS|
+Waterfall: P pull, B build, C create, W waiting for dependencies, S start, H healthy
+Critical path (*): db → web (10.0s)
+`)
+}
@@ -37,21 +37,43 @@ import (
 )
 
 func (s *composeService) Up(ctx context.Context, project *types.Project, options api.UpOptions) error { //nolint:gocyclo
+	var recorder *timings
+	if options.Timings {
+		recorder = newTimings()
+		ctx = withTimings(ctx, recorder)
+	}
 	err := progress.Run(ctx, tracing.SpanWrapFunc("project/up", tracing.ProjectOptions(ctx, project), func(ctx context.Context) error {
-		err := s.create(ctx, project, options.Create)
+		if err := s.runProjectHooks(ctx, project, hookPreUp, options.Start.Attach); err != nil {
+			return err
+		}
+		if options.Start.Attach != nil {
+			return s.create(ctx, project, options.Create)
+		}
+		// as we don't attach to containers, each service can be started as soon as it's ready
+		err := s.createAndStart(ctx, project, options.Create, func(ctx context.Context, service types.ServiceConfig) error {
+			containers, err := s.getContainers(ctx, project.Name, oneOffExclude, true)
+			if err != nil {
+				return err
+			}
+			return s.startService(ctx, project, service, containers, nil, options.Start.WaitTimeout)
+		})
 		if err != nil {
 			return err
 		}
-		if options.Start.Attach == nil {
-			return s.start(ctx, project.Name, options.Start, nil)
+		if err := s.start(ctx, project.Name, options.Start, nil); err != nil {
+			return err
 		}
-		return nil
+		return s.runProjectHooks(ctx, project, hookPostUp, nil)
 	}), s.stdinfo())
 	if err != nil {
 		return err
 	}
 
 	if options.Start.Attach == nil {
+		if recorder != nil {
+			recorder.waitHealthy(ctx)
+			return recorder.write(s.stdinfo(), project)
+		}
 		return err
 	}
 	if s.dryRun {
@@ -100,7 +122,8 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 				isDockerDesktopActive := s.isDesktopIntegrationActive()
 				tracing.KeyboardMetrics(ctx, options.Start.NavigationMenu, isDockerDesktopActive, isWatchConfigured)
 
-				formatter.NewKeyboardManager(ctx, isDockerDesktopActive, isWatchConfigured, signalChan, s.watch)
+				formatter.NewKeyboardManager(ctx, isDockerDesktopActive, isWatchConfigured, signalChan, kEvents, s.watch,
+					project.ServiceNames(), menuActions{s: s, project: project})
 				if options.Start.Watch {
 					formatter.KeyboardManager.StartWatch(ctx, doneCh, project, options)
 				}
@@ -136,6 +159,8 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 				return nil
 			case event := <-kEvents:
 				formatter.KeyboardManager.HandleKeyEvents(event, ctx, doneCh, project, options)
+				// the menu may have released and re-acquired the keyboard
+				kEvents = formatter.KeyboardManager.KeyEvents
 			}
 		}
 	})
@@ -166,8 +191,28 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 		})
 	}
 
+	listener := printer.HandleEvent
+	if options.Start.NavigationMenu {
+		listener = func(event api.ContainerEvent) {
+			isLog := event.Type == api.ContainerEventLog || event.Type == api.ContainerEventErr
+			if isLog && formatter.KeyboardManager != nil && formatter.KeyboardManager.IsLogMuted(event.Service) {
+				return
+			}
+			printer.HandleEvent(event)
+		}
+	}
+
 	// We use the parent context without cancellation as we manage sigterm to stop the stack
-	err = s.start(context.WithoutCancel(ctx), project.Name, options.Start, printer.HandleEvent)
+	err = s.startAndThen(context.WithoutCancel(ctx), project.Name, options.Start, listener, func(ctx context.Context) error {
+		if err := s.runProjectHooks(ctx, project, hookPostUp, options.Start.Attach); err != nil {
+			return err
+		}
+		if recorder != nil {
+			recorder.waitHealthy(ctx)
+			return recorder.write(s.stdinfo(), project)
+		}
+		return nil
+	})
 	if err != nil && !isTerminated.Load() { // Ignore error if the process is terminated
 		return err
 	}
@@ -187,3 +232,49 @@ func (s *composeService) Up(ctx context.Context, project *types.Project, options
 	}
 	return err
 }
+
+// menuActions runs the navigation menu service actions on the project managed by up
+type menuActions struct {
+	s       *composeService
+	project *types.Project
+}
+
+func (m menuActions) Restart(ctx context.Context, service string) error {
+	return m.s.restart(ctx, m.project.Name, api.RestartOptions{
+		Project:  m.project,
+		Services: []string{service},
+	})
+}
+
+func (m menuActions) Stop(ctx context.Context, service string) error {
+	return m.s.stop(ctx, m.project.Name, api.StopOptions{
+		Project:  m.project,
+		Services: []string{service},
+	})
+}
+
+func (m menuActions) Start(ctx context.Context, service string) error {
+	// start walks the whole project, so restrict it to the service and its dependencies
+	project, err := m.project.WithSelectedServices([]string{service})
+	if err != nil {
+		return err
+	}
+	return m.s.start(ctx, project.Name, api.StartOptions{
+		Project:  project,
+		Services: []string{service},
+	}, nil)
+}
+
+func (m menuActions) Shell(ctx context.Context, service string) error {
+	_, err := m.s.Exec(ctx, m.project.Name, api.RunOptions{
+		Service:     service,
+		Command:     []string{"sh"},
+		Tty:         m.s.dockerCli.Out().IsTerminal(),
+		Interactive: true,
+	})
+	return err
+}
+
+func (m menuActions) Ps(ctx context.Context) ([]api.ContainerSummary, error) {
+	return m.s.Ps(ctx, m.project.Name, api.PsOptions{Project: m.project})
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package compose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/compose-spec/compose-go/v2/types"
+	moby "github.com/docker/docker/api/types"
+	containerType "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+)
+
+func TestMenuActionsStartOnlySelectedService(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	api, cli := prepareMocks(mockCtrl)
+	tested := &composeService{
+		dockerCli: cli,
+	}
+	project := &types.Project{
+		Name: strings.ToLower(testProject),
+		Services: types.Services{
+			"service1": {Name: "service1"},
+			"service2": {Name: "service2"},
+		},
+	}
+
+	api.EXPECT().ContainerList(gomock.Any(), containerType.ListOptions{
+		Filters: filters.NewArgs(
+			projectFilter(strings.ToLower(testProject)),
+			oneOffFilter(false),
+		),
+		All: true,
+	}).Return([]moby.Container{
+		testContainer("service1", "123", false),
+		testContainer("service2", "456", false),
+	}, nil)
+	// only the selected service is started, service2 stays stopped
+	api.EXPECT().ContainerStart(gomock.Any(), "123", containerType.StartOptions{}).Return(nil)
+
+	err := menuActions{s: tested, project: project}.Start(context.Background(), "service1")
+	assert.NilError(t, err)
+}
@@ -23,9 +23,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	gosync "sync"
 	"time"
 
 	"github.com/compose-spec/compose-go/v2/types"
@@ -95,6 +97,8 @@ func (s *composeService) watch(ctx context.Context, syncChannel chan bool, proje
 	}
 	eg, ctx := errgroup.WithContext(ctx)
 	watching := false
+	watched := map[string]*watchedService{}
+	rebuilds := newSharedRebuilds()
 	options.LogTo.Register(api.WatchLogger)
 	for i := range project.Services {
 		service := project.Services[i]
@@ -164,7 +168,7 @@ func (s *composeService) watch(ctx context.Context, syncChannel chan bool, proje
 				success, err := trigger.Extensions.Get("x-initialSync", &initialSync)
 				if err == nil && success && initialSync && (trigger.Action == types.WatchActionSync || trigger.Action == types.WatchActionSyncRestart) {
 					// Need to check initial files are in container that are meant to be synched from watch action
-					err := s.initialSync(ctx, project, service, trigger, ignore, syncer)
+					err := s.initialSync(ctx, project, service, trigger, ignore, syncer, false)
 					if err != nil {
 						return err
 					}
@@ -188,18 +192,27 @@ func (s *composeService) watch(ctx context.Context, syncChannel chan bool, proje
 			return err
 		}
 		watching = true
+		ws := &watchedService{service: service, triggers: config.Watch, ignore: ignore, commands: make(chan api.WatchCommandAction, 8), rebuilds: rebuilds}
+		watched[service.Name] = ws
+		rebuilds.watch(service.Name, config.Watch)
 		eg.Go(func() error {
 			defer func() {
 				if err := watcher.Close(); err != nil {
 					logrus.Debugf("Error closing watcher for service %s: %v", service.Name, err)
 				}
 			}()
-			return s.watchEvents(ctx, project, service.Name, options, watcher, syncer, config.Watch)
+			return s.watchEvents(ctx, project, service.Name, options, watcher, syncer, config.Watch, ws)
 		})
 	}
 	if !watching {
 		return fmt.Errorf("none of the selected services is configured for watch, consider setting an 'develop' section")
 	}
+	if options.Commands != nil {
+		eg.Go(func() error {
+			dispatchWatchCommands(ctx, options, watched)
+			return nil
+		})
+	}
 	options.LogTo.Log(api.WatchLogger, "Watch enabled")
 
 	for {
@@ -213,21 +226,209 @@ func (s *composeService) watch(ctx context.Context, syncChannel chan bool, proje
 	}
 }
 
-func (s *composeService) watchEvents(ctx context.Context, project *types.Project, name string, options api.WatchOptions, watcher watch.Notify, syncer sync.Syncer, triggers []types.Trigger) error {
+// watchedService holds the watch configuration of a service, so it can be driven by watch commands
+type watchedService struct {
+	service  types.ServiceConfig
+	triggers []types.Trigger
+	ignore   watch.PathMatcher
+	paused   pausedChanges
+	// commands are applied by the service event loop, so they never run concurrently with a batch of changes
+	commands chan api.WatchCommandAction
+	// rebuilds is shared by the watched services, so services sharing a build context are rebuilt once per change
+	rebuilds *sharedRebuilds
+}
+
+// sharedRebuilds records the changes which got a service rebuilt by the batch of another service sharing its build
+// context, so the service doesn't rebuild again when the same changes show up in its own batch.
+type sharedRebuilds struct {
+	mu       gosync.Mutex
+	triggers map[string][]types.Trigger
+	handled  map[string][]string
+}
+
+func newSharedRebuilds() *sharedRebuilds {
+	return &sharedRebuilds{
+		triggers: map[string][]types.Trigger{},
+		handled:  map[string][]string{},
+	}
+}
+
+func (r *sharedRebuilds) watch(service string, triggers []types.Trigger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.triggers[service] = triggers
+}
+
+// claim returns the services to rebuild for the changes seen by service, which are none if these changes already
+// got service rebuilt along with another one
+func (r *sharedRebuilds) claim(service string, services []string, changed []string) []string {
+	if r == nil {
+		return services
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	handled := r.handled[service]
+	if len(changed) > 0 && !slices.ContainsFunc(changed, func(p string) bool {
+		return !slices.Contains(handled, p)
+	}) {
+		r.handled[service] = slices.DeleteFunc(handled, func(p string) bool {
+			return slices.Contains(changed, p)
+		})
+		return nil
+	}
+	for _, other := range services {
+		if other == service {
+			continue
+		}
+		for _, p := range changed {
+			if r.watches(other, p) && !slices.Contains(r.handled[other], p) {
+				r.handled[other] = append(r.handled[other], p)
+			}
+		}
+	}
+	return services
+}
+
+// watches returns true if service has a rebuild trigger for path, so the change shows up in its own batch
+func (r *sharedRebuilds) watches(service string, path string) bool {
+	for _, trigger := range r.triggers[service] {
+		if trigger.Action == types.WatchActionRebuild && path != "" && pathutil.IsChild(trigger.Path, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// pausedChanges holds the file changes of a watched service while it is paused, so they are applied on resume.
+// It is only used by the service event loop.
+type pausedChanges struct {
+	paused  bool
+	pending []fileEvent
+}
+
+func (p *pausedChanges) pause() {
+	p.paused = true
+}
+
+func (p *pausedChanges) isPaused() bool {
+	return p.paused
+}
+
+// hold queues the batch if the service is paused, returning false if it has to be handled now
+func (p *pausedChanges) hold(batch []fileEvent) bool {
+	if !p.paused {
+		return false
+	}
+	for _, e := range batch {
+		if !slices.Contains(p.pending, e) {
+			p.pending = append(p.pending, e)
+		}
+	}
+	return true
+}
+
+// resume returns the changes queued while paused
+func (p *pausedChanges) resume() []fileEvent {
+	pending := p.pending
+	p.paused = false
+	p.pending = nil
+	return pending
+}
+
+// dispatchWatchCommands forwards the commands received on options.Commands to the event loop of the watched
+// service until ctx is done
+func dispatchWatchCommands(ctx context.Context, options api.WatchOptions, watched map[string]*watchedService) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case cmd := <-options.Commands:
+			ws, ok := watched[cmd.Service]
+			if !ok {
+				options.LogTo.Err(api.WatchLogger, fmt.Sprintf("service %q is not watched", cmd.Service))
+				continue
+			}
+			select {
+			case ws.commands <- cmd.Action:
+			default:
+				options.LogTo.Err(api.WatchLogger, fmt.Sprintf("Too many pending watch commands for service %q, ignoring %s", cmd.Service, cmd.Action))
+			}
+		}
+	}
+}
+
+func (s *composeService) handleWatchCommand(ctx context.Context, project *types.Project, options api.WatchOptions, ws *watchedService, syncer sync.Syncer, action api.WatchCommandAction) error {
+	name := ws.service.Name
+	switch action {
+	case api.WatchPause:
+		ws.paused.pause()
+		options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Watch paused for service %q", name))
+	case api.WatchResume:
+		pending := ws.paused.resume()
+		options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Watch resumed for service %q", name))
+		if len(pending) > 0 {
+			return s.handleWatchBatch(ctx, project, name, options, pending, syncer, ws.rebuilds)
+		}
+	case api.WatchSync:
+		options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Syncing all watched files for service %q...", name))
+		for _, trigger := range ws.triggers {
+			if trigger.Action != types.WatchActionSync && trigger.Action != types.WatchActionSyncRestart {
+				continue
+			}
+			if checkIfPathAlreadyBindMounted(trigger.Path, ws.service.Volumes) {
+				continue
+			}
+			if err := s.initialSync(ctx, project, ws.service, trigger, ws.ignore, syncer, true); err != nil {
+				return err
+			}
+		}
+		options.LogTo.Log(api.WatchLogger, fmt.Sprintf("service %q synced", name))
+	case api.WatchRebuild:
+		if ws.service.Build == nil {
+			return fmt.Errorf("service has no build section")
+		}
+		if options.Build == nil {
+			return fmt.Errorf("--no-build is incompatible with rebuild")
+		}
+		return s.handleWatchBatch(ctx, project, name, options, []fileEvent{{Action: types.WatchActionRebuild}}, syncer, ws.rebuilds)
+	default:
+		return fmt.Errorf("unsupported watch command %q", action)
+	}
+	return nil
+}
+
+// watchEvents handles file change events for a service and the watch commands sent to ws, one at a time, queuing
+// changes while the service is paused. A nil ws means the service can't be driven by commands.
+func (s *composeService) watchEvents(ctx context.Context, project *types.Project, name string, options api.WatchOptions,
+	watcher watch.Notify, syncer sync.Syncer, triggers []types.Trigger, ws *watchedService) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	ignores := make([]watch.PathMatcher, len(triggers))
+	includes := make([]watch.PathMatcher, len(triggers))
 	for i, trigger := range triggers {
 		ignore, err := watch.NewDockerPatternMatcher(trigger.Path, trigger.Ignore)
 		if err != nil {
 			return err
 		}
 		ignores[i] = ignore
+		include, err := includeMatcher(trigger)
+		if err != nil {
+			return err
+		}
+		includes[i] = include
 	}
 
 	events := make(chan fileEvent)
 	batchEvents := batchDebounceEvents(ctx, s.clock, quietPeriod, events)
+	var (
+		commands <-chan api.WatchCommandAction
+		rebuilds *sharedRebuilds
+	)
+	if ws != nil {
+		commands = ws.commands
+		rebuilds = ws.rebuilds
+	}
 	quit := make(chan bool)
 	go func() {
 		for {
@@ -235,10 +436,18 @@ func (s *composeService) watchEvents(ctx context.Context, project *types.Project
 			case <-ctx.Done():
 				quit <- true
 				return
+			case action := <-commands:
+				if err := s.handleWatchCommand(ctx, project, options, ws, syncer, action); err != nil {
+					options.LogTo.Err(api.WatchLogger, fmt.Sprintf("Failed to %s service %q: %v", action, name, err))
+				}
 			case batch := <-batchEvents:
+				if ws != nil && ws.paused.hold(batch) {
+					logrus.Debugf("watch paused: service[%s] queuing %d changes", name, len(batch))
+					continue
+				}
 				start := time.Now()
 				logrus.Debugf("batch start: service[%s] count[%d]", name, len(batch))
-				if err := s.handleWatchBatch(ctx, project, name, options, batch, syncer); err != nil {
+				if err := s.handleWatchBatch(ctx, project, name, options, batch, syncer, rebuilds); err != nil {
 					logrus.Warnf("Error handling changed files for service %s: %v", name, err)
 				}
 				logrus.Debugf("batch complete: service[%s] duration[%s] count[%d]",
@@ -259,7 +468,7 @@ func (s *composeService) watchEvents(ctx context.Context, project *types.Project
 			hostPath := event.Path()
 			for i, trigger := range triggers {
 				logrus.Debugf("change for %s - comparing with %s", hostPath, trigger.Path)
-				if fileEvent := maybeFileEvent(trigger, hostPath, ignores[i]); fileEvent != nil {
+				if fileEvent := maybeFileEvent(trigger, hostPath, ignores[i], includes[i]); fileEvent != nil {
 					events <- *fileEvent
 				}
 			}
@@ -267,11 +476,24 @@ func (s *composeService) watchEvents(ctx context.Context, project *types.Project
 	}
 }
 
-// maybeFileEvent returns a file event object if hostPath is valid for the provided trigger and ignore
-// rules.
+// includeMatcher returns a matcher for the `x-include` patterns declared by a trigger, or nil
+// if the trigger applies to all files under its path.
+func includeMatcher(trigger types.Trigger) (watch.PathMatcher, error) {
+	var include []string
+	if _, err := trigger.Extensions.Get("x-include", &include); err != nil {
+		return nil, fmt.Errorf("invalid x-include for watch path %q: %w", trigger.Path, err)
+	}
+	if len(include) == 0 {
+		return nil, nil
+	}
+	return watch.NewDockerPatternMatcher(trigger.Path, include)
+}
+
+// maybeFileEvent returns a file event object if hostPath is valid for the provided trigger, ignore
+// and include rules. A nil include matcher accepts any path.
 //
 // Any errors are logged as warnings and nil (no file event) is returned.
-func maybeFileEvent(trigger types.Trigger, hostPath string, ignore watch.PathMatcher) *fileEvent {
+func maybeFileEvent(trigger types.Trigger, hostPath string, ignore watch.PathMatcher, include watch.PathMatcher) *fileEvent {
 	if !pathutil.IsChild(trigger.Path, hostPath) {
 		return nil
 	}
@@ -286,6 +508,18 @@ func maybeFileEvent(trigger types.Trigger, hostPath string, ignore watch.PathMat
 		return nil
 	}
 
+	if include != nil {
+		isIncluded, err := include.Matches(hostPath)
+		if err != nil {
+			logrus.Warnf("error include matching %q: %v", hostPath, err)
+			return nil
+		}
+		if !isIncluded {
+			logrus.Debugf("%s is not matching any include pattern", hostPath)
+			return nil
+		}
+	}
+
 	var containerPath string
 	if trigger.Target != "" {
 		rel, err := filepath.Rel(trigger.Path, hostPath)
@@ -481,14 +715,31 @@ func (t tarDockerClient) Untar(ctx context.Context, id string, archive io.ReadCl
 	})
 }
 
-func (s *composeService) handleWatchBatch(ctx context.Context, project *types.Project, serviceName string, options api.WatchOptions, batch []fileEvent, syncer sync.Syncer) error {
+//nolint:gocyclo
+func (s *composeService) handleWatchBatch(ctx context.Context, project *types.Project, serviceName string, options api.WatchOptions,
+	batch []fileEvent, syncer sync.Syncer, rebuilds *sharedRebuilds) error {
 	pathMappings := make([]sync.PathMapping, len(batch))
 	restartService := false
 	for i := range batch {
 		if batch[i].Action == types.WatchActionRebuild {
-			options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Rebuilding service %q after changes were detected...", serviceName))
-			// restrict the build to ONLY this service, not any of its dependencies
-			options.Build.Services = []string{serviceName}
+			var changed []string
+			for _, e := range batch {
+				if e.Action == types.WatchActionRebuild {
+					changed = append(changed, e.HostPath)
+				}
+			}
+			services := rebuilds.claim(serviceName, servicesSharingBuildContext(project, serviceName, changed), changed)
+			if len(services) == 0 {
+				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("service %q already rebuilt after changes were detected", serviceName))
+				return nil
+			}
+			if len(services) > 1 {
+				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Rebuilding services %s after changes were detected...", strings.Join(services, ", ")))
+			} else {
+				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Rebuilding service %q after changes were detected...", serviceName))
+			}
+			// restrict the build to ONLY the affected services, not any of their dependencies
+			options.Build.Services = services
 			imageNameToIdMap, err := s.build(ctx, project, *options.Build, nil)
 
 			if err != nil {
@@ -500,10 +751,32 @@ func (s *composeService) handleWatchBatch(ctx context.Context, project *types.Pr
 				s.pruneDanglingImagesOnRebuild(ctx, project.Name, imageNameToIdMap)
 			}
 
-			options.LogTo.Log(api.WatchLogger, fmt.Sprintf("service %q successfully built", serviceName))
+			var recreate []string
+			for _, name := range services {
+				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("service %q successfully built", name))
+				service := project.Services[name]
+				if !isStartFirst(service) {
+					recreate = append(recreate, name)
+					continue
+				}
+				if err := s.canStartFirst(ctx, project, service); err != nil {
+					logrus.Warnf("can't replace service %q without downtime, falling back to stop-first: %v", name, err)
+					recreate = append(recreate, name)
+					continue
+				}
+				err := s.recreateServiceStartFirst(ctx, project, service, imageNameToIdMap)
+				if err != nil {
+					options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Failed to replace service %q after update. Error: %v", name, err))
+					continue
+				}
+				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("service %q replaced", name))
+			}
+			if len(recreate) == 0 {
+				return nil
+			}
 
 			err = s.create(ctx, project, api.CreateOptions{
-				Services: []string{serviceName},
+				Services: recreate,
 				Inherit:  true,
 				Recreate: api.RecreateForce,
 			})
@@ -514,7 +787,7 @@ func (s *composeService) handleWatchBatch(ctx context.Context, project *types.Pr
 
 			err = s.start(ctx, project.Name, api.StartOptions{
 				Project:  project,
-				Services: []string{serviceName},
+				Services: recreate,
 			}, nil)
 			if err != nil {
 				options.LogTo.Log(api.WatchLogger, fmt.Sprintf("Application failed to start after update. Error: %v", err))
@@ -553,6 +826,83 @@ func (s *composeService) handleWatchBatch(ctx context.Context, project *types.Pr
 	return nil
 }
 
+// recreateServiceStartFirst replaces the running containers of a service with ones using the
+// freshly built image, starting each replacement before the container it replaces is removed.
+func (s *composeService) recreateServiceStartFirst(ctx context.Context, project *types.Project, service types.ServiceConfig, imageIDs map[string]string) error {
+	observed, err := s.getContainers(ctx, project.Name, oneOffExclude, true)
+	if err != nil {
+		return err
+	}
+	c := newConvergence(project.ServiceNames(), observed, s)
+	if err := c.resolveServiceReferences(&service); err != nil {
+		return err
+	}
+	if digest, ok := imageIDs[api.GetImageNameOrDefault(service, project.Name)]; ok {
+		service.CustomLabels = service.CustomLabels.Add(api.ImageDigestLabel, digest)
+	}
+
+	containers := observed.filter(isService(service.Name))
+	if len(containers) == 0 {
+		return fmt.Errorf("service %q has no container to replace", service.Name)
+	}
+	for _, container := range containers {
+		if _, err := s.recreateContainerStartFirst(ctx, project, service, container, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// servicesSharingBuildContext returns serviceName and every other service of the project whose
+// build context or additional contexts contain one of the changed paths, so that a library
+// shared by several images gets all of them rebuilt. Services are returned in dependency order,
+// so that a service is rebuilt and recreated after the ones it depends on.
+func servicesSharingBuildContext(project *types.Project, serviceName string, changed []string) []string {
+	affected := map[string]bool{serviceName: true}
+	for name, service := range project.Services {
+		if service.Build == nil || affected[name] {
+			continue
+		}
+		contexts := []string{service.Build.Context}
+		for _, c := range service.Build.AdditionalContexts {
+			contexts = append(contexts, c)
+		}
+	contextsLoop:
+		for _, c := range contexts {
+			// only local contexts can be affected by a file change
+			if !filepath.IsAbs(c) {
+				continue
+			}
+			for _, p := range changed {
+				if pathutil.IsChild(c, p) {
+					affected[name] = true
+					break contextsLoop
+				}
+			}
+		}
+	}
+
+	// visit services one at a time so dependencies are rebuilt before the services depending on them
+	services := make([]string, 0, len(affected))
+	err := InDependencyOrder(context.Background(), project, func(_ context.Context, name string) error {
+		if affected[name] {
+			services = append(services, name)
+		}
+		return nil
+	}, func(t *graphTraversal) {
+		t.maxConcurrency = 1
+	})
+	if err != nil {
+		// can't happen, as the project has been validated and has no dependency cycle
+		services = services[:0]
+		for name := range affected {
+			services = append(services, name)
+		}
+		sort.Strings(services)
+	}
+	return services
+}
+
 // writeWatchSyncMessage prints out a message about the sync for the changed paths.
 func writeWatchSyncMessage(log api.LogConsumer, serviceName string, pathMappings []sync.PathMapping, restart bool) {
 	action := "Syncing"
@@ -606,7 +956,8 @@ func (s *composeService) pruneDanglingImagesOnRebuild(ctx context.Context, proje
 
 // Walks develop.watch.path and checks which files should be copied inside the container
 // ignores develop.watch.ignore, Dockerfile, compose files, bind mounted paths and .git
-func (s *composeService) initialSync(ctx context.Context, project *types.Project, service types.ServiceConfig, trigger types.Trigger, ignore watch.PathMatcher, syncer sync.Syncer) error {
+// When force is set, all files are copied whatever their modification time.
+func (s *composeService) initialSync(ctx context.Context, project *types.Project, service types.ServiceConfig, trigger types.Trigger, ignore watch.PathMatcher, syncer sync.Syncer, force bool) error {
 	dockerFileIgnore, err := watch.NewDockerPatternMatcher("/", []string{"Dockerfile", "*compose*.y*ml"})
 	if err != nil {
 		return err
@@ -617,10 +968,28 @@ func (s *composeService) initialSync(ctx context.Context, project *types.Project
 	}
 	ignoreInitialSync := watch.NewCompositeMatcher(ignore, dockerFileIgnore, triggerIgnore)
 
-	pathsToCopy, err := s.initialSyncFiles(ctx, project, service, trigger, ignoreInitialSync)
+	var since time.Time
+	if !force {
+		since, err = s.imageCreatedTime(ctx, project, service.Name)
+		if err != nil {
+			return err
+		}
+	}
+	pathsToCopy, err := initialSyncFiles(service, trigger, ignoreInitialSync, since)
+	if err != nil {
+		return err
+	}
+
+	include, err := includeMatcher(trigger)
 	if err != nil {
 		return err
 	}
+	if include != nil {
+		pathsToCopy = slices.DeleteFunc(pathsToCopy, func(p sync.PathMapping) bool {
+			matches, _ := include.Matches(p.HostPath)
+			return !matches
+		})
+	}
 
 	return syncer.Sync(ctx, service, pathsToCopy)
 }
@@ -628,15 +997,11 @@ func (s *composeService) initialSync(ctx context.Context, project *types.Project
 // Syncs files from develop.watch.path if thy have been modified after the image has been created
 //
 //nolint:gocyclo
-func (s *composeService) initialSyncFiles(ctx context.Context, project *types.Project, service types.ServiceConfig, trigger types.Trigger, ignore watch.PathMatcher) ([]sync.PathMapping, error) {
+func initialSyncFiles(service types.ServiceConfig, trigger types.Trigger, ignore watch.PathMatcher, timeImageCreated time.Time) ([]sync.PathMapping, error) {
 	fi, err := os.Stat(trigger.Path)
 	if err != nil {
 		return nil, err
 	}
-	timeImageCreated, err := s.imageCreatedTime(ctx, project, service.Name)
-	if err != nil {
-		return nil, err
-	}
 	var pathsToCopy []sync.PathMapping
 	switch mode := fi.Mode(); {
 	case mode.IsDir():
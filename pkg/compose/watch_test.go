@@ -176,7 +176,7 @@ func TestWatch_Sync(t *testing.T) {
 				Path:   "/rebuild",
 				Action: "rebuild",
 			},
-		})
+		}, nil)
 		assert.NilError(t, err)
 	}()
 
@@ -235,3 +235,178 @@ func (f *fakeSyncer) Sync(_ context.Context, _ types.ServiceConfig, paths []sync
 	f.synced <- paths
 	return nil
 }
+
+func TestMaybeFileEventInclude(t *testing.T) {
+	trigger := types.Trigger{
+		Path:   "/src",
+		Action: types.WatchActionSync,
+		Target: "/work",
+		Extensions: types.Extensions{
+			"x-include": []any{"**/*.tmpl"},
+		},
+	}
+	ignore, err := watch.NewDockerPatternMatcher(trigger.Path, nil)
+	assert.NilError(t, err)
+	include, err := includeMatcher(trigger)
+	assert.NilError(t, err)
+
+	event := maybeFileEvent(trigger, "/src/views/index.tmpl", ignore, include)
+	assert.Assert(t, event != nil)
+	assert.Equal(t, event.ContainerPath, "/work/views/index.tmpl")
+
+	assert.Assert(t, maybeFileEvent(trigger, "/src/main.go", ignore, include) == nil)
+	assert.Assert(t, maybeFileEvent(trigger, "/src/main.go", ignore, nil) != nil)
+}
+
+func TestServicesSharingBuildContext(t *testing.T) {
+	project := &types.Project{
+		Services: types.Services{
+			"api": {
+				Name:  "api",
+				Build: &types.BuildConfig{Context: "/repo/api"},
+			},
+			"worker": {
+				Name:      "worker",
+				DependsOn: types.DependsOnConfig{"api": {Condition: types.ServiceConditionStarted}},
+				Build: &types.BuildConfig{
+					Context:            "/repo/worker",
+					AdditionalContexts: types.Mapping{"lib": "/repo/lib"},
+				},
+			},
+			"monolith": {
+				Name:      "monolith",
+				DependsOn: types.DependsOnConfig{"worker": {Condition: types.ServiceConditionStarted}},
+				Build:     &types.BuildConfig{Context: "/repo"},
+			},
+			"db": {
+				Name:  "db",
+				Image: "postgres",
+			},
+			"remote": {
+				Name:  "remote",
+				Build: &types.BuildConfig{Context: "https://github.com/docker/compose.git"},
+			},
+		},
+	}
+
+	assert.DeepEqual(t, servicesSharingBuildContext(project, "api", []string{"/repo/lib/util.go"}),
+		[]string{"api", "worker", "monolith"})
+	assert.DeepEqual(t, servicesSharingBuildContext(project, "api", []string{"/repo/api/main.go"}),
+		[]string{"api", "monolith"})
+	assert.DeepEqual(t, servicesSharingBuildContext(project, "api", []string{"/elsewhere/file"}),
+		[]string{"api"})
+}
+
+func TestSharedRebuildsClaim(t *testing.T) {
+	rebuilds := newSharedRebuilds()
+	rebuilds.watch("api", []types.Trigger{{Path: "/repo/api", Action: types.WatchActionRebuild}})
+	rebuilds.watch("worker", []types.Trigger{
+		{Path: "/repo/lib", Action: types.WatchActionRebuild},
+		{Path: "/repo/worker", Action: types.WatchActionSync, Target: "/app"},
+	})
+
+	changed := []string{"/repo/lib/util.go"}
+	assert.DeepEqual(t, rebuilds.claim("api", []string{"api", "worker"}, changed), []string{"api", "worker"})
+	// worker was rebuilt along with api, its own batch for the same change is skipped once
+	assert.Assert(t, rebuilds.claim("worker", []string{"worker", "api"}, changed) == nil)
+	assert.DeepEqual(t, rebuilds.claim("worker", []string{"worker", "api"}, changed), []string{"worker", "api"})
+
+	// changes worker doesn't watch for rebuild don't skip its next batch
+	synced := []string{"/repo/worker/main.go"}
+	assert.DeepEqual(t, rebuilds.claim("api", []string{"api", "worker"}, synced), []string{"api", "worker"})
+	assert.DeepEqual(t, rebuilds.claim("worker", []string{"worker"}, synced), []string{"worker"})
+
+	var none *sharedRebuilds
+	assert.DeepEqual(t, none.claim("api", []string{"api"}, changed), []string{"api"})
+}
+
+func TestWatchCommandPauseResume(t *testing.T) {
+	s := composeService{}
+	ws := &watchedService{service: types.ServiceConfig{Name: "test"}}
+	options := api.WatchOptions{LogTo: stdLogger{}}
+
+	project := &types.Project{Services: types.Services{"test": ws.service}}
+	syncer := &fakeSyncer{synced: make(chan []sync.PathMapping, 1)}
+
+	err := s.handleWatchCommand(context.Background(), project, options, ws, syncer, api.WatchPause)
+	assert.NilError(t, err)
+	assert.Assert(t, ws.paused.isPaused())
+
+	// changes made while paused are queued, and applied once on resume
+	change := fileEvent{PathMapping: sync.PathMapping{HostPath: "/src/a.go", ContainerPath: "/work/a.go"}, Action: types.WatchActionSync}
+	assert.Assert(t, ws.paused.hold([]fileEvent{change}))
+	assert.Assert(t, ws.paused.hold([]fileEvent{change}))
+
+	err = s.handleWatchCommand(context.Background(), project, options, ws, syncer, api.WatchResume)
+	assert.NilError(t, err)
+	assert.Assert(t, !ws.paused.isPaused())
+	assert.DeepEqual(t, <-syncer.synced, []sync.PathMapping{change.PathMapping})
+	assert.Assert(t, !ws.paused.hold([]fileEvent{change}))
+
+	err = s.handleWatchCommand(context.Background(), project, options, ws, syncer, api.WatchRebuild)
+	assert.ErrorContains(t, err, "no build section")
+}
+
+func TestWatchCommandsInEventLoop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	cli := mocks.NewMockCli(mockCtrl)
+	cli.EXPECT().Err().Return(streams.NewOut(os.Stderr)).AnyTimes()
+	apiClient := mocks.NewMockAPIClient(mockCtrl)
+	apiClient.EXPECT().ContainerList(gomock.Any(), gomock.Any()).Return([]moby.Container{
+		testContainer("test", "123", false),
+	}, nil).AnyTimes()
+	cli.EXPECT().Client().Return(apiClient).AnyTimes()
+
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	t.Cleanup(cancelFunc)
+
+	proj := types.Project{
+		Name:     "myProjectName",
+		Services: types.Services{"test": {Name: "test"}},
+	}
+	watcher := testWatcher{
+		events: make(chan watch.FileEvent),
+		errors: make(chan error),
+	}
+	triggers := []types.Trigger{{Path: "/sync", Action: "sync", Target: "/work"}}
+	ws := &watchedService{service: proj.Services["test"], triggers: triggers, commands: make(chan api.WatchCommandAction, 1)}
+	syncer := newFakeSyncer()
+	clock := clockwork.NewFakeClock()
+	// commands are handled by the event loop, in turn with the changes
+	ws.commands <- api.WatchPause
+	go func() {
+		service := composeService{
+			dockerCli: cli,
+			clock:     clock,
+		}
+		err := service.watchEvents(ctx, &proj, "test", api.WatchOptions{LogTo: stdLogger{}}, watcher, syncer, triggers, ws)
+		assert.NilError(t, err)
+	}()
+
+	dispatched := make(chan api.WatchCommand)
+	go dispatchWatchCommands(ctx, api.WatchOptions{LogTo: stdLogger{}, Commands: dispatched}, map[string]*watchedService{"test": ws})
+	// the event loop pauses the service as soon as it receives the command, before handling any batch
+	require.Eventually(t, func() bool {
+		return len(ws.commands) == 0
+	}, time.Second, time.Millisecond)
+
+	watcher.Events() <- watch.NewFileEvent("/sync/changed")
+	clock.BlockUntil(2)
+	clock.Advance(quietPeriod)
+	select {
+	case batch := <-syncer.synced:
+		t.Fatalf("received unexpected events while paused: %v", batch)
+	case <-time.After(100 * time.Millisecond):
+		// expected
+	}
+
+	dispatched <- api.WatchCommand{Service: "test", Action: api.WatchResume}
+	select {
+	case actual := <-syncer.synced:
+		require.ElementsMatch(t, []sync.PathMapping{
+			{HostPath: "/sync/changed", ContainerPath: "/work/changed"},
+		}, actual)
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for the changes queued while paused")
+	}
+}
@@ -228,6 +228,20 @@ func (mr *MockServiceMockRecorder) List(ctx, options any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), ctx, options)
 }
 
+// Load mocks base method.
+func (m *MockService) Load(ctx context.Context, options api.LoadOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockServiceMockRecorder) Load(ctx, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockService)(nil).Load), ctx, options)
+}
+
 // Logs mocks base method.
 func (m *MockService) Logs(ctx context.Context, projectName string, consumer api.LogConsumer, options api.LogOptions) error {
 	m.ctrl.T.Helper()
@@ -284,6 +298,20 @@ func (mr *MockServiceMockRecorder) Port(ctx, projectName, service, port, options
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Port", reflect.TypeOf((*MockService)(nil).Port), ctx, projectName, service, port, options)
 }
 
+// Prune mocks base method.
+func (m *MockService) Prune(ctx context.Context, projectName string, options api.PruneOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prune", ctx, projectName, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Prune indicates an expected call of Prune.
+func (mr *MockServiceMockRecorder) Prune(ctx, projectName, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockService)(nil).Prune), ctx, projectName, options)
+}
+
 // Ps mocks base method.
 func (m *MockService) Ps(ctx context.Context, projectName string, options api.PsOptions) ([]api.ContainerSummary, error) {
 	m.ctrl.T.Helper()
@@ -341,6 +369,20 @@ func (mr *MockServiceMockRecorder) Push(ctx, project, options any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockService)(nil).Push), ctx, project, options)
 }
 
+// RefreshSecrets mocks base method.
+func (m *MockService) RefreshSecrets(ctx context.Context, project *types.Project, options api.RefreshSecretsOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSecrets", ctx, project, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefreshSecrets indicates an expected call of RefreshSecrets.
+func (mr *MockServiceMockRecorder) RefreshSecrets(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSecrets", reflect.TypeOf((*MockService)(nil).RefreshSecrets), ctx, project, options)
+}
+
 // Remove mocks base method.
 func (m *MockService) Remove(ctx context.Context, projectName string, options api.RemoveOptions) error {
 	m.ctrl.T.Helper()
@@ -384,6 +426,20 @@ func (mr *MockServiceMockRecorder) RunOneOffContainer(ctx, project, opts any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunOneOffContainer", reflect.TypeOf((*MockService)(nil).RunOneOffContainer), ctx, project, opts)
 }
 
+// Save mocks base method.
+func (m *MockService) Save(ctx context.Context, project *types.Project, options api.SaveOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, project, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockServiceMockRecorder) Save(ctx, project, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockService)(nil).Save), ctx, project, options)
+}
+
 // Scale mocks base method.
 func (m *MockService) Scale(ctx context.Context, project *types.Project, options api.ScaleOptions) error {
 	m.ctrl.T.Helper()
@@ -18,6 +18,8 @@ package progress
 
 import (
 	"time"
+
+	"github.com/docker/compose/v2/pkg/api"
 )
 
 // EventStatus indicates the status of an action
@@ -51,6 +53,7 @@ const (
 type Event struct {
 	ID         string
 	ParentID   string
+	Kind       api.ProgressEventKind
 	Text       string
 	Status     EventStatus
 	StatusText string
@@ -171,6 +174,12 @@ func NewEvent(id string, status EventStatus, statusText string) Event {
 	}
 }
 
+// WithKind sets the kind of resource the event is about
+func (e Event) WithKind(kind api.ProgressEventKind) Event {
+	e.Kind = kind
+	return e
+}
+
 func (e *Event) stop() {
 	e.endTime = time.Now()
 	e.spinner.Stop()
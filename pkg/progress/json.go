@@ -21,24 +21,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/compose/v2/pkg/api"
 )
 
 type jsonWriter struct {
-	out    io.Writer
-	done   chan bool
-	dryRun bool
-}
-
-type jsonMessage struct {
-	DryRun   bool   `json:"dry-run,omitempty"`
-	Tail     bool   `json:"tail,omitempty"`
-	ID       string `json:"id,omitempty"`
-	ParentID string `json:"parent_id,omitempty"`
-	Text     string `json:"text,omitempty"`
-	Status   string `json:"status,omitempty"`
-	Current  int64  `json:"current,omitempty"`
-	Total    int64  `json:"total,omitempty"`
-	Percent  int    `json:"percent,omitempty"`
+	out     io.Writer
+	done    chan bool
+	dryRun  bool
+	mtx     sync.Mutex
+	started map[string]time.Time
 }
 
 func (p *jsonWriter) Start(ctx context.Context) error {
@@ -51,20 +45,61 @@ func (p *jsonWriter) Start(ctx context.Context) error {
 }
 
 func (p *jsonWriter) Event(e Event) {
-	var message = &jsonMessage{
-		DryRun:   p.dryRun,
-		Tail:     false,
-		ID:       e.ID,
-		Text:     e.Text,
-		Status:   e.StatusText,
-		ParentID: e.ParentID,
-		Current:  e.Current,
-		Total:    e.Total,
-		Percent:  e.Percent,
+	now := time.Now()
+	message := api.ProgressEvent{
+		Version:   api.ProgressEventVersion,
+		Kind:      eventKind(e),
+		DryRun:    p.dryRun,
+		ID:        e.ID,
+		ParentID:  e.ParentID,
+		State:     eventState(e.Status),
+		Text:      e.Text,
+		Status:    e.StatusText,
+		Timestamp: now,
+		Current:   e.Current,
+		Total:     e.Total,
+		Percent:   e.Percent,
 	}
-	marshal, err := json.Marshal(message)
-	if err == nil {
-		_, _ = fmt.Fprintln(p.out, string(marshal))
+
+	p.mtx.Lock()
+	if p.started == nil {
+		p.started = map[string]time.Time{}
+	}
+	key := e.ParentID + "/" + e.ID
+	started, ok := p.started[key]
+	if !ok {
+		started = now
+		p.started[key] = now
+	}
+	p.mtx.Unlock()
+	message.StartedAt = &started
+	if e.Status != Working {
+		message.Duration = now.Sub(started).Seconds()
+	}
+	if e.Status == Error {
+		message.Error = &api.ProgressError{Message: e.StatusText}
+	}
+	p.write(message)
+}
+
+// eventKind returns the kind of resource an event is about, events which don't set it are about other operations
+func eventKind(e Event) api.ProgressEventKind {
+	if e.Kind == "" {
+		return api.ProgressKindOther
+	}
+	return e.Kind
+}
+
+func eventState(status EventStatus) api.ProgressState {
+	switch status {
+	case Done:
+		return api.ProgressStateDone
+	case Warning:
+		return api.ProgressStateWarning
+	case Error:
+		return api.ProgressStateError
+	default:
+		return api.ProgressStateWorking
 	}
 }
 
@@ -75,13 +110,16 @@ func (p *jsonWriter) Events(events []Event) {
 }
 
 func (p *jsonWriter) TailMsgf(msg string, args ...interface{}) {
-	var message = &jsonMessage{
-		DryRun: p.dryRun,
-		Tail:   true,
-		ID:     "",
-		Text:   fmt.Sprintf(msg, args...),
-		Status: "",
-	}
+	p.write(api.ProgressEvent{
+		Version:   api.ProgressEventVersion,
+		DryRun:    p.dryRun,
+		Tail:      true,
+		Text:      fmt.Sprintf(msg, args...),
+		Timestamp: time.Now(),
+	})
+}
+
+func (p *jsonWriter) write(message api.ProgressEvent) {
 	marshal, err := json.Marshal(message)
 	if err == nil {
 		_, _ = fmt.Fprintln(p.out, string(marshal))
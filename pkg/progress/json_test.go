@@ -0,0 +1,73 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/pkg/api"
+)
+
+func TestJSONWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &jsonWriter{out: &out, done: make(chan bool)}
+	w.Event(CreatingEvent("Container demo-web-1").WithKind(api.ProgressKindContainerState))
+	w.Event(Event{ID: "a1b2c3", ParentID: "web", Kind: api.ProgressKindPullLayer, Status: Working, Text: "Downloading", Current: 50, Total: 200, Percent: 25})
+	w.Event(ErrorMessageEvent("Container demo-web-1", "port is already allocated").WithKind(api.ProgressKindContainerState))
+	w.Event(NewEvent("Network demo_default", Done, "Created").WithKind(api.ProgressKindNetwork))
+	w.Event(NewEvent("Synchronized File Shares", Done, ""))
+	w.TailMsgf("%d containers failed", 1)
+
+	var events []api.ProgressEvent
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var event api.ProgressEvent
+		assert.NilError(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, event.Version, api.ProgressEventVersion)
+		assert.Check(t, !event.Timestamp.IsZero())
+		events = append(events, event)
+	}
+	assert.Equal(t, len(events), 6)
+
+	assert.Equal(t, events[0].Kind, api.ProgressKindContainerState)
+	assert.Equal(t, events[0].State, api.ProgressStateWorking)
+	assert.Equal(t, events[0].Status, "Creating")
+	assert.Check(t, events[0].Error == nil)
+
+	assert.Equal(t, events[1].Kind, api.ProgressKindPullLayer)
+	assert.Equal(t, events[1].ParentID, "web")
+	assert.Equal(t, events[1].Current, int64(50))
+	assert.Equal(t, events[1].Total, int64(200))
+	assert.Equal(t, events[1].Percent, 25)
+
+	assert.Equal(t, events[2].State, api.ProgressStateError)
+	assert.DeepEqual(t, events[2].Error, &api.ProgressError{Message: "port is already allocated"})
+	assert.Check(t, events[2].StartedAt.Equal(*events[0].StartedAt))
+	assert.Check(t, events[2].Duration >= 0)
+
+	assert.Equal(t, events[3].Kind, api.ProgressKindNetwork)
+	assert.Equal(t, events[3].State, api.ProgressStateDone)
+
+	assert.Equal(t, events[4].Kind, api.ProgressKindOther)
+
+	assert.Check(t, events[5].Tail)
+	assert.Equal(t, events[5].Text, "1 containers failed")
+}
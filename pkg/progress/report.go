@@ -0,0 +1,312 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReportFile is the file the junit and markdown writers write their report to. When not set, the report is written
+// to the progress output once the operation completes
+var ReportFile string
+
+var (
+	// reports collects the reports of all the operations run by the command, so ReportFile covers all of them
+	reports    []report
+	reportsMtx sync.Mutex
+)
+
+// report records the events of an operation, to be rendered as a JUnit or Markdown report
+type report struct {
+	title string
+	start time.Time
+	end   time.Time
+	steps []reportStep
+	// err is the error the operation failed with, if any
+	err error
+}
+
+// failed tells whether a step failed: either it reported an error, or it was still in progress when the operation
+// failed
+func (r report) failed(step reportStep) bool {
+	return step.status == Error || step.status == Working && r.err != nil
+}
+
+// reportStep is the outcome of an operation on a resource
+type reportStep struct {
+	id         string
+	text       string
+	status     EventStatus
+	statusText string
+	start      time.Time
+	end        time.Time
+}
+
+func (s reportStep) duration() time.Duration {
+	if s.end.IsZero() {
+		return 0
+	}
+	return s.end.Sub(s.start)
+}
+
+type reportWriter struct {
+	format  string
+	out     io.Writer
+	display Writer
+	done    chan bool
+	mtx     sync.Mutex
+	report  report
+	steps   map[string]int
+}
+
+func newReportWriter(format string, out io.Writer, dryRun bool, progressTitle string) *reportWriter {
+	w := &reportWriter{
+		format: format,
+		out:    out,
+		done:   make(chan bool),
+		report: report{title: progressTitle, start: time.Now()},
+		steps:  map[string]int{},
+	}
+	if ReportFile != "" {
+		// as the report is written to a file, progress can still be displayed
+		w.display = &plainWriter{out: out, done: make(chan bool), dryRun: dryRun}
+	}
+	return w
+}
+
+func (w *reportWriter) Start(ctx context.Context) error {
+	if w.display != nil {
+		return w.display.Start(ctx)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-w.done:
+		return nil
+	}
+}
+
+func (w *reportWriter) Event(e Event) {
+	if w.display != nil {
+		w.display.Event(e)
+	}
+	if e.ParentID != "" {
+		// only report the resources, not the details of the operations on them like image layers
+		return
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	now := time.Now()
+	i, ok := w.steps[e.ID]
+	if !ok {
+		i = len(w.report.steps)
+		w.steps[e.ID] = i
+		w.report.steps = append(w.report.steps, reportStep{id: e.ID, start: now})
+	}
+	step := &w.report.steps[i]
+	step.text = e.Text
+	step.status = e.Status
+	step.statusText = e.StatusText
+	if e.Status == Working {
+		step.end = time.Time{}
+	} else {
+		step.end = now
+	}
+}
+
+func (w *reportWriter) Events(events []Event) {
+	for _, e := range events {
+		w.Event(e)
+	}
+}
+
+// setError records the error the operation failed with
+func (w *reportWriter) setError(err error) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.report.err = err
+}
+
+func (w *reportWriter) TailMsgf(msg string, args ...interface{}) {
+	if w.display != nil {
+		w.display.TailMsgf(msg, args...)
+	}
+}
+
+func (w *reportWriter) Stop() {
+	w.mtx.Lock()
+	w.report.end = time.Now()
+	current := w.report
+	w.mtx.Unlock()
+
+	if w.display != nil {
+		w.display.Stop()
+	} else {
+		w.done <- true
+	}
+
+	var err error
+	if ReportFile == "" {
+		err = w.write(w.out, []report{current})
+	} else {
+		reportsMtx.Lock()
+		defer reportsMtx.Unlock()
+		reports = append(reports, current)
+		var f *os.File
+		f, err = os.Create(ReportFile)
+		if err == nil {
+			err = w.write(f, reports)
+			_ = f.Close()
+		}
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(w.out, "failed to write %s report: %v\n", w.format, err)
+	}
+}
+
+func (w *reportWriter) write(out io.Writer, reports []report) error {
+	if w.format == ModeJUnit {
+		return writeJUnit(out, reports)
+	}
+	return writeMarkdown(out, reports)
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes a JUnit XML report, with a test suite per operation and a test case per resource
+func writeJUnit(out io.Writer, reports []report) error {
+	var suites junitTestSuites
+	for _, r := range reports {
+		suite := junitTestSuite{
+			Name:      r.title,
+			Tests:     len(r.steps),
+			Time:      seconds(r.end.Sub(r.start)),
+			Timestamp: r.start.UTC().Format(time.RFC3339),
+		}
+		for _, step := range r.steps {
+			testCase := junitTestCase{
+				Name:      step.id,
+				ClassName: r.title,
+				Time:      seconds(step.duration()),
+				SystemOut: strings.TrimSpace(step.text + " " + step.statusText),
+			}
+			switch {
+			case step.status == Error:
+				suite.Failures++
+				testCase.Failure = &junitMessage{Message: step.statusText}
+			case r.failed(step):
+				suite.Failures++
+				testCase.Failure = &junitMessage{Message: "Interrupted: " + step.statusText}
+			case step.status == Warning:
+				suite.Skipped++
+				testCase.Skipped = &junitMessage{Message: step.statusText}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
+// writeMarkdown writes a summary table per operation, with the status and duration of each resource
+func writeMarkdown(out io.Writer, reports []report) error {
+	var b strings.Builder
+	for i, r := range reports {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		var failed int
+		for _, step := range r.steps {
+			if r.failed(step) {
+				failed++
+			}
+		}
+		fmt.Fprintf(&b, "### %s\n\n", r.title)
+		fmt.Fprintf(&b, "%d resources, %d failed in %s\n\n", len(r.steps), failed, seconds(r.end.Sub(r.start))+"s")
+		b.WriteString("| Resource | Status | Duration |\n")
+		b.WriteString("|----------|--------|----------|\n")
+		for _, step := range r.steps {
+			status := strings.TrimSpace(step.text + " " + step.statusText)
+			fmt.Fprintf(&b, "| %s | %s %s | %ss |\n", markdownCell(step.id), statusMark(step.status), markdownCell(status), seconds(step.duration()))
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func statusMark(status EventStatus) string {
+	switch status {
+	case Done:
+		return "✔"
+	case Error:
+		return "✘"
+	case Warning:
+		return "!"
+	default:
+		return "…"
+	}
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.1f", d.Seconds())
+}
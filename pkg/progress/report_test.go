@@ -0,0 +1,118 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package progress
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func testReport() report {
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	return report{
+		title: "Running",
+		start: start,
+		end:   start.Add(3 * time.Second),
+		steps: []reportStep{
+			{id: "Network demo_default", status: Done, statusText: "Created", start: start, end: start.Add(100 * time.Millisecond)},
+			{id: "Container demo-db-1", status: Done, statusText: "Healthy", start: start, end: start.Add(2500 * time.Millisecond)},
+			{id: "Container demo-web-1", status: Error, statusText: "port is already allocated", start: start, end: start.Add(time.Second)},
+			{id: "Container demo-worker-1", status: Working, statusText: "Starting", start: start},
+		},
+		err: errors.New("port is already allocated"),
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var b bytes.Buffer
+	err := writeJUnit(&b, []report{testReport()})
+	assert.NilError(t, err)
+	assert.Equal(t, b.String(), `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="Running" tests="4" failures="2" skipped="0" time="3.0" timestamp="2024-06-01T10:00:00Z">
+    <testcase name="Network demo_default" classname="Running" time="0.1">
+      <system-out>Created</system-out>
+    </testcase>
+    <testcase name="Container demo-db-1" classname="Running" time="2.5">
+      <system-out>Healthy</system-out>
+    </testcase>
+    <testcase name="Container demo-web-1" classname="Running" time="1.0">
+      <failure message="port is already allocated"></failure>
+      <system-out>port is already allocated</system-out>
+    </testcase>
+    <testcase name="Container demo-worker-1" classname="Running" time="0.0">
+      <failure message="Interrupted: Starting"></failure>
+      <system-out>Starting</system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`)
+}
+
+func TestReportWorkingSucceeded(t *testing.T) {
+	r := testReport()
+	r.err = nil
+	var b bytes.Buffer
+	err := writeJUnit(&b, []report{r})
+	assert.NilError(t, err)
+	assert.Check(t, strings.Contains(b.String(), `tests="4" failures="1"`))
+	assert.Check(t, !strings.Contains(b.String(), "Interrupted"))
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	err := writeMarkdown(&b, []report{testReport()})
+	assert.NilError(t, err)
+	assert.Equal(t, b.String(), `### Running
+
+4 resources, 2 failed in 3.0s
+
+| Resource | Status | Duration |
+|----------|--------|----------|
+| Network demo_default | ✔ Created | 0.1s |
+| Container demo-db-1 | ✔ Healthy | 2.5s |
+| Container demo-web-1 | ✘ port is already allocated | 1.0s |
+| Container demo-worker-1 | … Starting | 0.0s |
+`)
+}
+
+func TestReportWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newReportWriter(ModeMarkdown, &out, false, "Pulling")
+	go func() {
+		_ = w.Start(context.Background())
+	}()
+	w.Events([]Event{
+		NewEvent("web", Working, "Pulling"),
+		{ID: "layer", ParentID: "web", Status: Working, StatusText: "Downloading"},
+		NewEvent("db", Working, "Pulling"),
+	})
+	w.Event(NewEvent("web", Done, "Pulled"))
+	w.Event(ErrorMessageEvent("db", "manifest unknown"))
+	w.Stop()
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, lines[2], "2 resources, 1 failed in 0.0s")
+	assert.Equal(t, lines[6], "| web | ✔ Pulled | 0.0s |")
+	assert.Equal(t, lines[7], "| db | ✘ manifest unknown | 0.0s |")
+}
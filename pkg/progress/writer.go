@@ -90,6 +90,8 @@ func RunWithStatus(ctx context.Context, pf progressFuncWithStatus, out *streams.
 		s, err := pf(ctx)
 		if err == nil {
 			result = s
+		} else if r, ok := w.(*reportWriter); ok {
+			r.setError(err)
 		}
 		return err
 	})
@@ -109,6 +111,10 @@ const (
 	ModeQuiet = "quiet"
 	// ModeJSON outputs a machine-readable JSON stream
 	ModeJSON = "json"
+	// ModeJUnit writes a JUnit XML report once completed
+	ModeJUnit = "junit"
+	// ModeMarkdown writes a Markdown summary once completed
+	ModeMarkdown = "markdown"
 )
 
 // Mode define how progress should be rendered, either as ModePlain or ModeTTY
@@ -132,6 +138,9 @@ func NewWriter(ctx context.Context, out *streams.Out, progressTitle string) (Wri
 	if tty {
 		return newTTYWriter(out, dryRun, progressTitle)
 	}
+	if Mode == ModeJUnit || Mode == ModeMarkdown {
+		return newReportWriter(Mode, out, dryRun, progressTitle), nil
+	}
 	if Mode == ModeJSON {
 		return &jsonWriter{
 			out:    out,
@@ -17,8 +17,18 @@
 package remote
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/buildkit/util/gitutil"
 )
 
 func cacheDir() (string, error) {
@@ -34,3 +44,221 @@ func cacheDir() (string, error) {
 	err = os.MkdirAll(path, 0o700)
 	return path, err
 }
+
+const (
+	// REMOTE_CACHE_TTL sets how long a git branch or tag reference resolved to a commit is used from the cache before
+	// being resolved again, as a duration
+	REMOTE_CACHE_TTL = "COMPOSE_REMOTE_CACHE_TTL"
+
+	// CacheEntryGit is the type of cache entries holding a git checkout
+	CacheEntryGit = "git"
+	// CacheEntryOCI is the type of cache entries holding the files of a Compose OCI artifact
+	CacheEntryOCI = "oci"
+)
+
+// CacheEntry describes a remote resource stored in the cache
+type CacheEntry struct {
+	// Type is the kind of remote resource, git or oci
+	Type string `json:"type"`
+	// Reference is the remote reference the entry was last loaded for
+	Reference string `json:"reference"`
+	// Digest is the commit or the manifest digest the reference resolved to
+	Digest string `json:"digest"`
+	// Resolved is the last time the reference was resolved to Digest
+	Resolved time.Time `json:"resolved"`
+	// LastUsed is the last time the entry was used to load a project
+	LastUsed time.Time `json:"lastUsed"`
+	// Path is the local directory of the entry
+	Path string `json:"-"`
+	// Size is the disk usage of the entry, in bytes
+	Size int64 `json:"-"`
+}
+
+func cacheTTL() (time.Duration, error) {
+	v := os.Getenv(REMOTE_CACHE_TTL)
+	if v == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s environment variable expects a duration: %w", REMOTE_CACHE_TTL, err)
+	}
+	return ttl, nil
+}
+
+// recordCacheEntry updates the metadata of a cache entry after it has been used
+func recordCacheEntry(local string, entry CacheEntry) error {
+	now := time.Now()
+	if entry.Resolved.IsZero() {
+		entry.Resolved = now
+	}
+	entry.LastUsed = now
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(local+".json", content, 0o600)
+}
+
+func readCacheEntry(local string) (CacheEntry, error) {
+	entry := CacheEntry{Path: local}
+	content, err := os.ReadFile(local + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		// entry created before metadata were recorded
+		return entry, nil
+	}
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(content, &entry)
+	return entry, err
+}
+
+// cachedResolution returns the cache entry for a reference if it has been resolved less than ttl ago
+func cachedResolution(cache string, typ string, reference string, ttl time.Duration) (CacheEntry, bool) {
+	if ttl <= 0 {
+		return CacheEntry{}, false
+	}
+	entries, err := listCacheEntries(cache)
+	if err != nil {
+		return CacheEntry{}, false
+	}
+	for _, entry := range entries {
+		if entry.Type == typ && entry.Reference == reference && time.Since(entry.Resolved) < ttl {
+			return entry, true
+		}
+	}
+	return CacheEntry{}, false
+}
+
+// ListCache returns the entries of the remote resources cache
+func ListCache() ([]CacheEntry, error) {
+	cache, err := cacheDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := listCacheEntries(cache)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		entries[i].Size, err = dirSize(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func listCacheEntries(cache string) ([]CacheEntry, error) {
+	dirs, err := os.ReadDir(cache)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []CacheEntry
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		entry, err := readCacheEntry(filepath.Join(cache, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if entry.Digest == "" {
+			entry.Digest = dir.Name()
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// PruneCache removes the cache entries which have not been used for longer than unusedFor, or all of them if zero
+func PruneCache(unusedFor time.Duration) ([]CacheEntry, error) {
+	entries, err := ListCache()
+	if err != nil {
+		return nil, err
+	}
+	var pruned []CacheEntry
+	for _, entry := range entries {
+		if unusedFor > 0 && time.Since(entry.LastUsed) < unusedFor {
+			continue
+		}
+		if err := os.RemoveAll(entry.Path); err != nil {
+			return pruned, err
+		}
+		if err := os.Remove(entry.Path + ".json"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return pruned, err
+		}
+		pruned = append(pruned, entry)
+	}
+	return pruned, nil
+}
+
+// RefreshCache resolves again the references of the cache entries, and fetches the ones which have moved
+func RefreshCache(ctx context.Context, dockerCli command.Cli) ([]CacheEntry, error) {
+	entries, err := ListCache()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var refreshed []CacheEntry
+	for _, entry := range entries {
+		if entry.Reference == "" || seen[entry.Reference] {
+			continue
+		}
+		seen[entry.Reference] = true
+		var local string
+		switch entry.Type {
+		case CacheEntryGit:
+			g := gitRemoteLoader{known: map[string]string{}}
+			ref, err := gitutil.ParseGitRef(entry.Reference)
+			if err != nil {
+				return refreshed, err
+			}
+			if commitSHA.MatchString(ref.Commit) {
+				// a commit never moves
+				continue
+			}
+			local, err = g.fetch(ctx, entry.Reference, ref, true)
+			if err != nil {
+				return refreshed, fmt.Errorf("refreshing %s: %w", entry.Reference, err)
+			}
+		case CacheEntryOCI:
+			g := ociRemoteLoader{dockerCli: dockerCli, known: map[string]string{}}
+			local, err = g.pull(ctx, entry.Reference)
+			if err != nil {
+				return refreshed, fmt.Errorf("refreshing %s: %w", entry.Reference, err)
+			}
+		default:
+			continue
+		}
+		updated, err := readCacheEntry(local)
+		if err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, updated)
+	}
+	return refreshed, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
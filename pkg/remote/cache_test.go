@@ -0,0 +1,74 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCacheEntries(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cache, err := cacheDir()
+	assert.NilError(t, err)
+
+	entries, err := ListCache()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+
+	branch := filepath.Join(cache, "1111111111111111111111111111111111111111")
+	assert.NilError(t, os.MkdirAll(branch, 0o700))
+	assert.NilError(t, os.WriteFile(filepath.Join(branch, "compose.yaml"), []byte("services: {}\n"), 0o600))
+	assert.NilError(t, recordCacheEntry(branch, CacheEntry{
+		Type:      CacheEntryGit,
+		Reference: "https://github.com/docker/compose.git#main",
+		Digest:    "1111111111111111111111111111111111111111",
+		Resolved:  time.Now().Add(-time.Hour),
+	}))
+	legacy := filepath.Join(cache, "abcd")
+	assert.NilError(t, os.MkdirAll(legacy, 0o700))
+
+	entries, err = ListCache()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].Reference, "https://github.com/docker/compose.git#main")
+	assert.Equal(t, entries[0].Size, int64(13))
+	assert.Equal(t, entries[1].Digest, "abcd")
+
+	_, ok := cachedResolution(cache, CacheEntryGit, "https://github.com/docker/compose.git#main", 0)
+	assert.Check(t, !ok)
+	_, ok = cachedResolution(cache, CacheEntryGit, "https://github.com/docker/compose.git#main", time.Minute)
+	assert.Check(t, !ok)
+	entry, ok := cachedResolution(cache, CacheEntryGit, "https://github.com/docker/compose.git#main", 2*time.Hour)
+	assert.Check(t, ok)
+	assert.Equal(t, entry.Path, branch)
+
+	pruned, err := PruneCache(time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pruned), 1)
+	assert.Equal(t, pruned[0].Digest, "abcd")
+
+	pruned, err = PruneCache(0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(pruned), 1)
+	_, err = os.Stat(branch + ".json")
+	assert.Check(t, os.IsNotExist(err))
+}
@@ -80,30 +80,10 @@ func (g gitRemoteLoader) Load(ctx context.Context, path string) (string, error)
 
 	local, ok := g.known[path]
 	if !ok {
-		if ref.Commit == "" {
-			ref.Commit = "HEAD" // default branch
-		}
-
-		err = g.resolveGitRef(ctx, path, ref)
-		if err != nil {
+		local, err = g.fetch(ctx, path, ref, false)
+		if err != nil || local == "" {
 			return "", err
 		}
-
-		cache, err := cacheDir()
-		if err != nil {
-			return "", fmt.Errorf("initializing remote resource cache: %w", err)
-		}
-
-		local = filepath.Join(cache, ref.Commit)
-		if _, err := os.Stat(local); os.IsNotExist(err) {
-			if g.offline {
-				return "", nil
-			}
-			err = g.checkout(ctx, local, ref)
-			if err != nil {
-				return "", err
-			}
-		}
 		g.known[path] = local
 	}
 	if ref.SubDir != "" {
@@ -119,6 +99,48 @@ func (g gitRemoteLoader) Load(ctx context.Context, path string) (string, error)
 	return local, err
 }
 
+// fetch checks out the commit a git reference resolves to in the remote resources cache, unless already there, and
+// returns the local checkout directory. Unless refresh is set, a branch or tag resolved less than the cache TTL ago
+// isn't resolved again
+func (g gitRemoteLoader) fetch(ctx context.Context, path string, ref *gitutil.GitRef, refresh bool) (string, error) {
+	if ref.Commit == "" {
+		ref.Commit = "HEAD" // default branch
+	}
+
+	cache, err := cacheDir()
+	if err != nil {
+		return "", fmt.Errorf("initializing remote resource cache: %w", err)
+	}
+
+	if !refresh && !commitSHA.MatchString(ref.Commit) {
+		ttl, err := cacheTTL()
+		if err != nil {
+			return "", err
+		}
+		if entry, ok := cachedResolution(cache, CacheEntryGit, path, ttl); ok {
+			ref.Commit = entry.Digest
+			return entry.Path, recordCacheEntry(entry.Path, entry)
+		}
+	}
+
+	err = g.resolveGitRef(ctx, path, ref)
+	if err != nil {
+		return "", err
+	}
+
+	local := filepath.Join(cache, ref.Commit)
+	if _, err := os.Stat(local); os.IsNotExist(err) {
+		if g.offline {
+			return "", nil
+		}
+		err = g.checkout(ctx, local, ref)
+		if err != nil {
+			return "", err
+		}
+	}
+	return local, recordCacheEntry(local, CacheEntry{Type: CacheEntryGit, Reference: path, Digest: ref.Commit})
+}
+
 func (g gitRemoteLoader) Dir(path string) string {
 	return g.known[path]
 }
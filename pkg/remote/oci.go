@@ -18,6 +18,7 @@ package remote
 
 import (
 	"context"
+	"crypto"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -31,11 +32,16 @@ import (
 	"github.com/docker/buildx/util/imagetools"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/compose/v2/internal/ocipush"
+	"github.com/opencontainers/go-digest"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 const OCI_REMOTE_ENABLED = "COMPOSE_EXPERIMENTAL_OCI_REMOTE"
 
+// OCI_TRUSTED_KEYS is the compose plugin configuration listing the public keys OCI remote resources must be signed
+// with, as a comma-separated list of PEM files
+const OCI_TRUSTED_KEYS = "oci-trusted-keys"
+
 func ociRemoteLoaderEnabled() (bool, error) {
 	if v := os.Getenv(OCI_REMOTE_ENABLED); v != "" {
 		enabled, err := strconv.ParseBool(v)
@@ -82,47 +88,123 @@ func (g ociRemoteLoader) Load(ctx context.Context, path string) (string, error)
 
 	local, ok := g.known[path]
 	if !ok {
-		ref, err := reference.ParseDockerRef(path[len(prefix):])
+		local, err = g.pull(ctx, path)
 		if err != nil {
 			return "", err
 		}
+		g.known[path] = local
+	}
 
-		opt, err := storeutil.GetImageConfig(g.dockerCli, nil)
-		if err != nil {
-			return "", err
-		}
-		resolver := imagetools.New(opt)
+	return filepath.Join(local, "compose.yaml"), nil
+}
 
-		content, descriptor, err := resolver.Get(ctx, ref.String())
-		if err != nil {
-			return "", err
-		}
+// pull fetches the files of the OCI artifact a path refers to in the remote resources cache, unless already there, and
+// returns the local directory
+func (g ociRemoteLoader) pull(ctx context.Context, path string) (string, error) {
+	ref, resolver, manifest, descriptor, err := g.resolve(ctx, path)
+	if err != nil {
+		return "", err
+	}
 
-		cache, err := cacheDir()
-		if err != nil {
-			return "", fmt.Errorf("initializing remote resource cache: %w", err)
+	cache, err := cacheDir()
+	if err != nil {
+		return "", fmt.Errorf("initializing remote resource cache: %w", err)
+	}
+
+	local := filepath.Join(cache, descriptor.Digest.Hex())
+	composeFile := filepath.Join(local, "compose.yaml")
+	if _, err = os.Stat(local); os.IsNotExist(err) {
+		err2 := g.pullComposeFiles(ctx, local, composeFile, manifest, ref, resolver)
+		if err2 != nil {
+			// we need to clean up the directory to be sure we won't let empty files present
+			_ = os.RemoveAll(local)
+			return "", err2
 		}
+	}
+	return local, recordCacheEntry(local, CacheEntry{Type: CacheEntryOCI, Reference: path, Digest: descriptor.Digest.String()})
+}
 
-		local = filepath.Join(cache, descriptor.Digest.Hex())
-		composeFile := filepath.Join(local, "compose.yaml")
-		if _, err = os.Stat(local); os.IsNotExist(err) {
-			var manifest v1.Manifest
-			err = json.Unmarshal(content, &manifest)
-			if err != nil {
-				return "", err
-			}
+// resolve fetches the manifest of the OCI artifact a path refers to, and verifies it according to the configured policy
+func (g ociRemoteLoader) resolve(ctx context.Context, path string) (reference.Named, *imagetools.Resolver, v1.Manifest, v1.Descriptor, error) {
+	var manifest v1.Manifest
+	ref, err := reference.ParseDockerRef(path[len(prefix):])
+	if err != nil {
+		return nil, nil, manifest, v1.Descriptor{}, err
+	}
 
-			err2 := g.pullComposeFiles(ctx, local, composeFile, manifest, ref, resolver)
-			if err2 != nil {
-				// we need to clean up the directory to be sure we won't let empty files present
-				_ = os.RemoveAll(local)
-				return "", err2
-			}
-		}
-		g.known[path] = local
+	opt, err := storeutil.GetImageConfig(g.dockerCli, nil)
+	if err != nil {
+		return nil, nil, manifest, v1.Descriptor{}, err
 	}
+	resolver := imagetools.New(opt)
 
-	return filepath.Join(local, "compose.yaml"), nil
+	content, descriptor, err := resolver.Get(ctx, ref.String())
+	if err != nil {
+		return nil, nil, manifest, v1.Descriptor{}, err
+	}
+
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, nil, manifest, v1.Descriptor{}, err
+	}
+	if (manifest.ArtifactType != "" && manifest.ArtifactType != ocipush.ComposeProjectArtifactType) ||
+		(manifest.ArtifactType == "" && manifest.Config.MediaType != ocipush.ComposeEmptyConfigMediaType) {
+		return nil, nil, manifest, v1.Descriptor{}, fmt.Errorf("%s is not a compose project OCI artifact, but %s", ref.String(), manifest.ArtifactType)
+	}
+	err = g.verify(ref, manifest)
+	if err != nil {
+		return nil, nil, manifest, v1.Descriptor{}, err
+	}
+	return ref, resolver, manifest, descriptor, nil
+}
+
+// fetchLayer gets the content of a layer, checking it matches the layer digest
+func fetchLayer(ctx context.Context, resolver *imagetools.Resolver, ref reference.Named, layer v1.Descriptor) ([]byte, error) {
+	digested, err := reference.WithDigest(ref, layer.Digest)
+	if err != nil {
+		return nil, err
+	}
+	content, _, err := resolver.Get(ctx, digested.String())
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(content) != layer.Digest {
+		return nil, fmt.Errorf("content of layer %s doesn't match its digest", layer.Digest)
+	}
+	return content, nil
+}
+
+// verify checks the artifact is signed by one of the trusted keys set in the compose plugin configuration, if any
+func (g ociRemoteLoader) verify(ref reference.Named, manifest v1.Manifest) error {
+	trusted, err := g.trustedKeys()
+	if err != nil {
+		return err
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+	err = ocipush.VerifyManifest(manifest, trusted)
+	if err != nil {
+		return fmt.Errorf("refusing to load %s: %w", ref.String(), err)
+	}
+	return nil
+}
+
+func (g ociRemoteLoader) trustedKeys() ([]crypto.PublicKey, error) {
+	config := g.dockerCli.ConfigFile().Plugins["compose"]
+	var keys []crypto.PublicKey
+	for _, path := range strings.Split(config[OCI_TRUSTED_KEYS], ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		key, err := ocipush.LoadPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading trusted keys for OCI remote resources: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
 }
 
 func (g ociRemoteLoader) Dir(path string) string {
@@ -140,21 +222,22 @@ func (g ociRemoteLoader) pullComposeFiles(ctx context.Context, local string, com
 		return err
 	}
 	defer f.Close() //nolint:errcheck
-	if (manifest.ArtifactType != "" && manifest.ArtifactType != ocipush.ComposeProjectArtifactType) ||
-		(manifest.ArtifactType == "" && manifest.Config.MediaType != ocipush.ComposeEmptyConfigMediaType) {
-		return fmt.Errorf("%s is not a compose project OCI artifact, but %s", ref.String(), manifest.ArtifactType)
-	}
 
-	for i, layer := range manifest.Layers {
-		digested, err := reference.WithDigest(ref, layer.Digest)
+	var composeLayers int
+	for _, layer := range manifest.Layers {
+		content, err := fetchLayer(ctx, resolver, ref, layer)
 		if err != nil {
 			return err
 		}
-		content, _, err := resolver.Get(ctx, digested.String())
-		if err != nil {
-			return err
+		if ocipush.IsProjectFile(layer) {
+			err = writeProjectFile(local, layer, content)
+			if err != nil {
+				return err
+			}
+			continue
 		}
-		if i > 0 {
+		composeLayers++
+		if composeLayers > 1 {
 			_, err = f.Write([]byte("\n---\n"))
 			if err != nil {
 				return err
@@ -168,4 +251,17 @@ func (g ociRemoteLoader) pullComposeFiles(ctx context.Context, local string, com
 	return nil
 }
 
+// writeProjectFile restores a project file published along with the Compose files at its path in the project directory
+func writeProjectFile(local string, layer v1.Descriptor, content []byte) error {
+	path := filepath.FromSlash(layer.Annotations[ocipush.ComposePathAnnotation])
+	if !filepath.IsLocal(path) || path == "compose.yaml" {
+		return fmt.Errorf("invalid path for project file layer %s: %q", layer.Digest, path)
+	}
+	target := filepath.Join(local, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(target, content, 0o600)
+}
+
 var _ loader.ResourceLoader = ociRemoteLoader{}
@@ -0,0 +1,193 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/buildkit/util/gitutil"
+	"github.com/opencontainers/go-digest"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	cp "github.com/otiai10/copy"
+
+	"github.com/docker/compose/v2/internal/ocipush"
+)
+
+// ProjectSourceFile is the file recording where a project pulled by PullProject comes from
+const ProjectSourceFile = ".compose-source.json"
+
+// ProjectSource records the remote reference a project was pulled from, and the files it was made of
+type ProjectSource struct {
+	// Reference is the oci:// or git reference the project was pulled from
+	Reference string `json:"reference"`
+	// Digest is the digest of the OCI artifact manifest, or the git commit
+	Digest string `json:"digest"`
+	// Files are the digests of the project files, by path relative to the project directory
+	Files map[string]string `json:"files"`
+}
+
+// PullProject fetches the project an oci:// or git reference refers to, and writes its files into dir
+func PullProject(ctx context.Context, dockerCli command.Cli, reference string, dir string) (*ProjectSource, error) {
+	if err := checkEmptyDir(dir); err != nil {
+		return nil, err
+	}
+
+	var (
+		source *ProjectSource
+		err    error
+	)
+	oci := ociRemoteLoader{dockerCli: dockerCli, known: map[string]string{}}
+	git := gitRemoteLoader{known: map[string]string{}}
+	switch {
+	case oci.Accept(reference):
+		source, err = oci.pullProject(ctx, reference, dir)
+	case git.Accept(reference):
+		source, err = git.pullProject(ctx, reference, dir)
+	default:
+		return nil, fmt.Errorf("%s is not an oci:// or git reference", reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	source.Files, err = digestFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.MarshalIndent(source, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(dir, ProjectSourceFile), content, 0o644)
+	return source, err
+}
+
+// ReadProjectSource reads the source of a project pulled by PullProject, nil if the project has not been pulled
+func ReadProjectSource(dir string) (*ProjectSource, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ProjectSourceFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var source ProjectSource
+	if err := json.Unmarshal(content, &source); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ProjectSourceFile, err)
+	}
+	return &source, nil
+}
+
+func (g ociRemoteLoader) pullProject(ctx context.Context, path string, dir string) (*ProjectSource, error) {
+	ref, resolver, manifest, descriptor, err := g.resolve(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	for _, layer := range manifest.Layers {
+		content, err := fetchLayer(ctx, resolver, ref, layer)
+		if err != nil {
+			return nil, err
+		}
+		if ocipush.IsProjectFile(layer) {
+			err = writeProjectFile(dir, layer, content)
+		} else {
+			err = writeComposeFile(dir, layer, content)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ProjectSource{Reference: path, Digest: descriptor.Digest.String()}, nil
+}
+
+// writeComposeFile writes a Compose file layer in the project directory, named after its original file name
+func writeComposeFile(dir string, layer v1.Descriptor, content []byte) error {
+	name := layer.Annotations["com.docker.compose.file"]
+	if name == "" || name != filepath.Base(name) || name == ProjectSourceFile {
+		return fmt.Errorf("invalid file name for Compose file layer %s: %q", layer.Digest, name)
+	}
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("artifact has more than one Compose file named %s", name)
+	}
+	return os.WriteFile(target, content, 0o644)
+}
+
+func (g gitRemoteLoader) pullProject(ctx context.Context, path string, dir string) (*ProjectSource, error) {
+	ref, err := gitutil.ParseGitRef(path)
+	if err != nil {
+		return nil, err
+	}
+	local, err := g.fetch(ctx, path, ref, true)
+	if err != nil {
+		return nil, err
+	}
+	err = cp.Copy(filepath.Join(local, ref.SubDir), dir, cp.Options{
+		Skip: func(_ os.FileInfo, src, _ string) (bool, error) {
+			return filepath.Base(src) == ".git", nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectSource{Reference: path, Digest: ref.Commit}, nil
+}
+
+func checkEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("directory %s is not empty", dir)
+	}
+	return nil
+}
+
+// digestFiles computes the digest of the files in a project directory
+func digestFiles(dir string) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = digest.FromBytes(content).String()
+		return nil
+	})
+	return files, err
+}
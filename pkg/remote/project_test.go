@@ -0,0 +1,69 @@
+/*
+   Copyright 2024 Docker Compose CLI authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/compose/v2/internal/ocipush"
+)
+
+func TestWriteProjectLayers(t *testing.T) {
+	dir := t.TempDir()
+	compose := ocipush.DescriptorForComposeFile("/src/compose.yaml", []byte("services: {}"))
+	assert.NilError(t, writeComposeFile(dir, compose, []byte("services: {}")))
+	assert.ErrorContains(t, writeComposeFile(dir, compose, []byte("services: {}")), "more than one Compose file named compose.yaml")
+
+	envFile := ocipush.DescriptorForProjectFile(ocipush.ComposeEnvFileMediaType, "env/app.env", []byte("A=1"))
+	assert.NilError(t, writeProjectFile(dir, envFile, []byte("A=1")))
+	content, err := os.ReadFile(filepath.Join(dir, "env", "app.env"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "A=1")
+
+	escaping := v1.Descriptor{Annotations: map[string]string{ocipush.ComposePathAnnotation: "../app.env"}}
+	assert.ErrorContains(t, writeProjectFile(dir, escaping, nil), "invalid path for project file layer")
+
+	files, err := digestFiles(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, files, map[string]string{
+		"compose.yaml": compose.Digest.String(),
+		"env/app.env":  envFile.Digest.String(),
+	})
+	assert.ErrorContains(t, checkEmptyDir(dir), "is not empty")
+	assert.NilError(t, checkEmptyDir(filepath.Join(dir, "missing")))
+}
+
+func TestReadProjectSource(t *testing.T) {
+	dir := t.TempDir()
+	source, err := ReadProjectSource(dir)
+	assert.NilError(t, err)
+	assert.Check(t, source == nil)
+
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, ProjectSourceFile), []byte(`{"reference":"oci://docker.io/test/app","digest":"sha256:1234","files":{"compose.yaml":"sha256:abcd"}}`), 0o600))
+	source, err = ReadProjectSource(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *source, ProjectSource{
+		Reference: "oci://docker.io/test/app",
+		Digest:    "sha256:1234",
+		Files:     map[string]string{"compose.yaml": "sha256:abcd"},
+	})
+}
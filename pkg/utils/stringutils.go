@@ -40,3 +40,11 @@ func StringToBool(s string) bool {
 	b, _ := strconv.ParseBool(s)
 	return b
 }
+
+// StringOrDash returns s, or a dash when it's empty, so empty values still show up as a table cell
+func StringOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}